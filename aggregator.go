@@ -0,0 +1,125 @@
+package easylog
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultAggregatorWindow is the window of an Aggregator created with a
+// non-positive one.
+const defaultAggregatorWindow = time.Minute
+
+// Aggregator counts recurring errors and logs one summary per distinct error
+// message and window through the global logger, instead of one entry per
+// occurrence.
+type Aggregator struct {
+	window time.Duration
+	stop   chan struct{}
+	done   chan struct{}
+
+	stopOnce sync.Once
+
+	mu      sync.Mutex
+	counts  map[string]*aggregate
+	stopped bool
+}
+
+// aggregate is the occurrences of one error message in the current window.
+type aggregate struct {
+	err   error
+	count int
+}
+
+// NewAggregator returns an Aggregator logging the errors recorded during each
+// window at its end, or each minute if window is not positive. Stop it to
+// release its goroutine.
+func NewAggregator(window time.Duration) *Aggregator {
+	if window <= 0 {
+		window = defaultAggregatorWindow
+	}
+	a := &Aggregator{
+		window: window,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		counts: make(map[string]*aggregate),
+	}
+	go a.run()
+	return a
+}
+
+// Record counts an occurrence of err, grouped with the errors of the same
+// message. A nil err, or any err once the Aggregator is stopped, is ignored.
+func (a *Aggregator) Record(err error) {
+	if err == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.stopped {
+		return
+	}
+	if agg, ok := a.counts[err.Error()]; ok {
+		agg.count++
+		return
+	}
+	a.counts[err.Error()] = &aggregate{err: err, count: 1}
+}
+
+// Stop logs the summaries of the current window and stops the Aggregator.
+// Errors recorded afterwards are ignored. Stop can be called more than once.
+func (a *Aggregator) Stop() {
+	a.stopOnce.Do(func() {
+		a.mu.Lock()
+		a.stopped = true
+		a.mu.Unlock()
+		close(a.stop)
+	})
+	<-a.done
+}
+
+func (a *Aggregator) run() {
+	defer close(a.done)
+	ticker := time.NewTicker(a.window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			a.flush()
+		case <-a.stop:
+			a.flush()
+			return
+		}
+	}
+}
+
+// flush logs a summary of every error recorded since the last flush, in
+// the order of their messages.
+func (a *Aggregator) flush() {
+	a.mu.Lock()
+	counts := a.counts
+	a.counts = make(map[string]*aggregate, len(counts))
+	a.mu.Unlock()
+
+	msgs := make([]string, 0, len(counts))
+	for msg := range counts {
+		msgs = append(msgs, msg)
+	}
+	sort.Strings(msgs)
+	// the caller and stacktrace would be those of the Aggregator
+	lg := globalLogger.CoreLogger().WithOptions(
+		zap.WithCaller(false),
+		zap.AddStacktrace(zap.LevelEnablerFunc(func(zapcore.Level) bool { return false })),
+	)
+	for _, msg := range msgs {
+		agg := counts[msg]
+		lg.Error("error summary",
+			zap.Error(agg.err),
+			zap.Int("count", agg.count),
+			zap.Duration("window", a.window),
+		)
+	}
+}
@@ -0,0 +1,57 @@
+package easylog
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAggregator(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+	a := NewAggregator(time.Hour)
+
+	for i := 0; i < 100; i++ {
+		a.Record(errors.New("connection refused"))
+	}
+	a.Record(nil)
+	a.Stop()
+
+	entry := decodeLine(t, buf.String())
+	if entry["msg"] != "error summary" || entry["error"] != "connection refused" || entry["count"] != 100.0 {
+		t.Errorf("got %v, want one summary of connection refused with count=100", entry)
+	}
+}
+
+func TestAggregatorWindow(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+	a := NewAggregator(20 * time.Millisecond)
+	defer a.Stop()
+
+	a.Record(errors.New("timeout"))
+	a.Record(errors.New("timeout"))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for buf.String() == "" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if entry := decodeLine(t, buf.String()); entry["count"] != 2.0 {
+		t.Errorf("got %v, want a summary with count=2 at the end of the window", entry)
+	}
+}
+
+func TestAggregatorStop(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+	a := NewAggregator(0)
+
+	a.Record(errors.New("refused"))
+	a.Stop()
+	a.Stop()
+	a.Record(errors.New("refused"))
+
+	if entries := decodeLines(t, buf.String()); len(entries) != 1 || entries[0]["count"] != 1.0 {
+		t.Errorf("got %v, want one summary with count=1", entries)
+	}
+	if n := len(a.counts); n != 0 {
+		t.Errorf("got %d errors recorded after Stop, want none", n)
+	}
+}
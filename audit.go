@@ -0,0 +1,223 @@
+package easylog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const auditChainKey = "chain"
+
+// auditState is shared by an audit core and all cores derived from it, so
+// that every line written to the file takes part in the same chain.
+type auditState struct {
+	mu       sync.Mutex
+	path     string
+	file     *os.File
+	prevHash string
+}
+
+// auditCore appends every entry to a file together with a chain field
+// holding sha256(prevHash + line), where line is the entry encoded
+// without the chain field. The entry is encoded once and the chain field is
+// inserted before its closing brace, so that removing the trailing
+// `,"chain":"<hex>"` restores exactly the hashed line.
+type auditCore struct {
+	zapcore.LevelEnabler
+	enc   zapcore.Encoder
+	state *auditState
+}
+
+// NewAuditLogger returns a Logger that appends tamper-evident entries to the
+// file at path. Each line carries a chain field hashing it together with the
+// previous line, so a modified, removed or reordered line breaks the chain.
+// Logging to an existing file continues its chain. Use VerifyAuditLog to
+// validate a file.
+func NewAuditLogger(path string) Logger {
+	core := &auditCore{
+		LevelEnabler: zapcore.DebugLevel,
+		enc:          zapcore.NewJSONEncoder(newEncoderConfig()),
+		state:        &auditState{path: path},
+	}
+	// the audit logger is used through its methods only, skip the wrapper frame
+	return newLogger(zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1)))
+}
+
+func (c *auditCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return &auditCore{
+		LevelEnabler: c.LevelEnabler,
+		enc:          enc,
+		state:        c.state,
+	}
+}
+
+func (c *auditCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *auditCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	s := c.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.open(); err != nil {
+		return err
+	}
+
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	line := bytes.TrimRight(buf.Bytes(), "\n")
+	n := len(line)
+	if n < 2 || line[0] != '{' || line[n-1] != '}' {
+		return fmt.Errorf("audit entry is not a JSON object")
+	}
+	hash := auditHash(s.prevHash, line)
+
+	suffix := auditChainSuffix(hash)
+	out := make([]byte, 0, n-1+len(suffix)+1)
+	out = append(out, line[:n-1]...)
+	out = append(out, suffix...)
+	out = append(out, '\n')
+	if _, err := s.file.Write(out); err != nil {
+		return err
+	}
+
+	s.prevHash = hash
+	return nil
+}
+
+func (c *auditCore) Sync() error {
+	s := c.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Sync()
+}
+
+// open lazily opens the audit file, resuming the chain of any existing lines.
+func (s *auditState) open() error {
+	if s.file != nil {
+		return nil
+	}
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	var prevHash string
+	err = readAuditLines(f, func(_ int, _ []byte, chain string) error {
+		prevHash = chain
+		return nil
+	})
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	s.file = f
+	s.prevHash = prevHash
+	return nil
+}
+
+// VerifyAuditLog validates the hash chain of a file written by
+// NewAuditLogger and reports the first line that does not match.
+func VerifyAuditLog(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var prevHash string
+	return readAuditLines(f, func(lineNo int, line []byte, chain string) error {
+		if auditHash(prevHash, line) != chain {
+			return fmt.Errorf("audit chain broken at line %d", lineNo)
+		}
+		prevHash = chain
+		return nil
+	})
+}
+
+// readAuditLines calls fn with every non-empty line of r, stripped of its
+// trailing chain field, and the chain value.
+func readAuditLines(r io.Reader, fn func(lineNo int, line []byte, chain string) error) error {
+	br := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, err := br.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if line = bytes.TrimRight(line, "\n"); len(line) > 0 {
+			original, chain, ok := splitAuditChain(line)
+			if !ok {
+				return fmt.Errorf("audit chain missing at line %d", lineNo)
+			}
+			if fnErr := fn(lineNo, original, chain); fnErr != nil {
+				return fnErr
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+// auditChainSuffix returns the chain field as written before the closing
+// brace of a line, including that brace.
+func auditChainSuffix(hash string) string {
+	return `,"` + auditChainKey + `":"` + hash + `"}`
+}
+
+// splitAuditChain removes the trailing chain field written by auditCore from
+// line and returns the hashed line and the chain value.
+func splitAuditChain(line []byte) ([]byte, string, bool) {
+	const prefix = `,"` + auditChainKey + `":"`
+	n := len(prefix) + hex.EncodedLen(sha256.Size) + len(`"}`)
+	if len(line) < n+1 {
+		return nil, "", false
+	}
+	suffix := line[len(line)-n:]
+	if !bytes.HasPrefix(suffix, []byte(prefix)) || !bytes.HasSuffix(suffix, []byte(`"}`)) {
+		return nil, "", false
+	}
+	chain := string(suffix[len(prefix) : n-2])
+	if _, err := hex.DecodeString(chain); err != nil {
+		return nil, "", false
+	}
+
+	original := make([]byte, 0, len(line)-n+1)
+	original = append(original, line[:len(line)-n]...)
+	return append(original, '}'), chain, true
+}
+
+func auditHash(prevHash string, line []byte) string {
+	h := sha256.New()
+	h.Write([]byte(prevHash))
+	h.Write(line)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,87 @@
+package easylog
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAuditLoggerChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l := NewAuditLogger(path)
+	l.Info("login", zap.String("actor", "alice"))
+	l.Info("update", zap.String("actor", "alice"))
+	l.Info("logout", zap.String("actor", "alice"))
+	l.Sync()
+
+	if err := VerifyAuditLog(path); err != nil {
+		t.Fatalf("valid chain: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(b), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3", len(lines))
+	}
+	lines[1] = bytes.Replace(lines[1], []byte("update"), []byte("delete"), 1)
+	if err := os.WriteFile(path, append(bytes.Join(lines, []byte("\n")), '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAuditLog(path); err == nil || err.Error() != "audit chain broken at line 2" {
+		t.Errorf("got %v, want the chain broken at line 2", err)
+	}
+}
+
+// mapMarshaler ranges over a map, so two encodings of it may differ.
+type mapMarshaler map[string]int
+
+func (m mapMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for k, v := range m {
+		enc.AddInt(k, v)
+	}
+	return nil
+}
+
+func TestAuditLoggerEncodesOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l := NewAuditLogger(path)
+	m := mapMarshaler{}
+	for i := 0; i < 16; i++ {
+		m[strconv.Itoa(i)] = i
+	}
+	for i := 0; i < 8; i++ {
+		l.Info("counts", zap.Object("counts", m))
+	}
+	l.Info("request", zap.Namespace("req"), zap.String("id", "42"))
+	l.Sync()
+
+	if err := VerifyAuditLog(path); err != nil {
+		t.Fatalf("valid chain: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(b), []byte("\n"))
+	var last map[string]interface{}
+	if err := json.Unmarshal(lines[len(lines)-1], &last); err != nil {
+		t.Fatal(err)
+	}
+	req, _ := last["req"].(map[string]interface{})
+	if req["id"] != "42" || req[auditChainKey] != nil {
+		t.Errorf("got req %v, want only the id", req)
+	}
+	if last[auditChainKey] == nil {
+		t.Errorf("got %v, want a top-level chain", last)
+	}
+}
@@ -0,0 +1,59 @@
+package easylog
+
+import (
+	"sync"
+
+	"github.com/logerror/easylog/pkg/option"
+	"go.uber.org/zap"
+)
+
+// Capture runs fn with the global logger replaced by one recording every
+// entry at any level, and returns the recorded entries. The previous global
+// logger is restored afterwards, even if fn panics. Capture is meant for
+// tests and must not run concurrently with other code replacing the global
+// logger. The entries have the Entry and Context fields of the LoggedEntry of
+// zaptest/observer, without linking that test package into programs.
+func Capture(fn func()) []LoggedEntry {
+	var (
+		mu      sync.Mutex
+		entries []LoggedEntry
+	)
+	core := newEntryCore(func(entry LoggedEntry) {
+		mu.Lock()
+		defer mu.Unlock()
+		entries = append(entries, entry)
+	})
+	l := newLogger(zap.New(core, zap.AddCaller(), zap.AddCallerSkip(option.CallerSkip-1)))
+
+	prevLogger := globalLogger
+	prevRawLogger := globalRawLogger
+	prevSugaredLogger := globalSugaredLogger
+	prevFuncLogger := globalFuncLogger
+	prevFuncSugaredLogger := globalFuncSugaredLogger
+	prevOtelLogger := globalOtelLogger
+	prevOtelSugaredLogger := globalOtelSugaredLogger
+	defer func() {
+		globalLogger = prevLogger
+		globalRawLogger = prevRawLogger
+		globalSugaredLogger = prevSugaredLogger
+		globalFuncLogger = prevFuncLogger
+		globalFuncSugaredLogger = prevFuncSugaredLogger
+		globalOtelLogger = prevOtelLogger
+		globalOtelSugaredLogger = prevOtelSugaredLogger
+	}()
+
+	globalRawLogger = l
+	globalLogger = l
+	globalSugaredLogger = l.SugaredLogger()
+	globalFuncLogger, globalFuncSugaredLogger = funcLoggers(l)
+	globalOtelLogger = l.otelLogger
+	globalOtelSugaredLogger = l.otelSugaredLogger
+	restoreZap := zap.ReplaceGlobals(l.logger)
+	defer restoreZap()
+
+	fn()
+
+	mu.Lock()
+	defer mu.Unlock()
+	return entries
+}
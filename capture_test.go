@@ -0,0 +1,43 @@
+package easylog
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestCapture(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+
+	entries := Capture(func() {
+		Info("captured", zap.String("k", "v"))
+	})
+	Info("after")
+
+	if len(entries) != 1 || entries[0].Message != "captured" || entries[0].ContextMap()["k"] != "v" {
+		t.Errorf("got entries %v, want captured with k=v", entries)
+	}
+	if entry := decodeLine(t, buf.String()); entry["msg"] != "after" {
+		t.Errorf("got msg %v after Capture, want after", entry["msg"])
+	}
+}
+
+func TestCaptureRestoresOnPanic(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("got no panic, want it propagated")
+			}
+		}()
+		Capture(func() {
+			panic("boom")
+		})
+	}()
+	Info("after")
+
+	if entry := decodeLine(t, buf.String()); entry["msg"] != "after" {
+		t.Errorf("got msg %v after Capture, want after", entry["msg"])
+	}
+}
@@ -0,0 +1,88 @@
+package easylog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const checksumKey = "_checksum"
+
+var checksumPool = buffer.NewPool()
+
+// newLineHash returns the hash named algo, "crc32" or "sha256", or nil if
+// it is unknown.
+func newLineHash(algo string) func() hash.Hash {
+	switch algo {
+	case "crc32":
+		return func() hash.Hash { return crc32.NewIEEE() }
+	case "sha256":
+		return sha256.New
+	}
+	return nil
+}
+
+// checksumEncoder appends the hex checksum of every encoded line, computed
+// over the line without its line ending, as the last field, _checksum. A
+// JSON line gets it before its closing brace, so that removing
+// `,"_checksum":"<hex>"` restores the hashed line; other lines get it as a
+// JSON object after a tab, as the console encoder renders fields.
+type checksumEncoder struct {
+	zapcore.Encoder
+	newHash    func() hash.Hash
+	lineEnding []byte
+}
+
+func newChecksumEncoder(enc zapcore.Encoder, newHash func() hash.Hash, lineEnding string) zapcore.Encoder {
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	return &checksumEncoder{
+		Encoder:    enc,
+		newHash:    newHash,
+		lineEnding: []byte(lineEnding),
+	}
+}
+
+func (e *checksumEncoder) Clone() zapcore.Encoder {
+	return &checksumEncoder{
+		Encoder:    e.Encoder.Clone(),
+		newHash:    e.newHash,
+		lineEnding: e.lineEnding,
+	}
+}
+
+func (e *checksumEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer buf.Free()
+
+	line := bytes.TrimSuffix(buf.Bytes(), e.lineEnding)
+	h := e.newHash()
+	_, _ = h.Write(line)
+	var sum [sha256.Size]byte
+	digest := h.Sum(sum[:0])
+
+	out := checksumPool.Get()
+	if n := len(line); n >= 2 && line[0] == '{' && line[n-1] == '}' {
+		_, _ = out.Write(line[:n-1])
+		if n > 2 {
+			out.AppendByte(',')
+		}
+	} else {
+		_, _ = out.Write(line)
+		out.AppendString("\t{")
+	}
+	out.AppendString(`"` + checksumKey + `":"`)
+	out.AppendString(hex.EncodeToString(digest))
+	out.AppendString(`"}`)
+	_, _ = out.Write(e.lineEnding)
+	return out, nil
+}
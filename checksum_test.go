@@ -0,0 +1,45 @@
+package easylog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/logerror/easylog/pkg/option"
+	"go.uber.org/zap"
+)
+
+var checksumField = regexp.MustCompile(`,"_checksum":"([0-9a-f]+)"}$`)
+
+func TestWithLineChecksum(t *testing.T) {
+	sums := map[string]func([]byte) string{
+		"crc32": func(b []byte) string { return fmt.Sprintf("%08x", crc32.ChecksumIEEE(b)) },
+		"sha256": func(b []byte) string {
+			sum := sha256.Sum256(b)
+			return hex.EncodeToString(sum[:])
+		},
+	}
+	for algo, sum := range sums {
+		t.Run(algo, func(t *testing.T) {
+			l, buf := newBufferLogger(t, option.WithLineChecksum(algo))
+
+			l.Info("shipped", zap.String("k", "v"))
+
+			line := strings.TrimSuffix(buf.String(), "\n")
+			m := checksumField.FindStringSubmatchIndex(line)
+			if m == nil {
+				t.Fatalf("got %q, want a trailing _checksum field", line)
+			}
+			rest := line[:m[0]] + "}"
+			if got, want := line[m[2]:m[3]], sum([]byte(rest)); got != want {
+				t.Errorf("got checksum %s, want %s of %q", got, want, rest)
+			}
+			// the line stays valid JSON
+			decodeLine(t, line)
+		})
+	}
+}
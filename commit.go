@@ -0,0 +1,38 @@
+package easylog
+
+import (
+	"runtime/debug"
+	"sync/atomic"
+)
+
+const commitKey = "commit"
+
+var (
+	// commit holds the commit set by SetCommit.
+	commit atomic.Value
+	// readBuildInfo reads the build info of the binary, replaced in tests.
+	readBuildInfo = debug.ReadBuildInfo
+)
+
+// SetCommit sets the commit logged by loggers built with
+// option.WithCommitField afterwards, e.g. from a variable set by
+// -ldflags "-X".
+func SetCommit(sha string) {
+	commit.Store(sha)
+}
+
+// buildCommit returns the commit set by SetCommit, falling back to the VCS
+// revision stamped in the build info of the binary.
+func buildCommit() string {
+	if sha, ok := commit.Load().(string); ok && sha != "" {
+		return sha
+	}
+	if info, ok := readBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,35 @@
+package easylog
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/logerror/easylog/pkg/option"
+)
+
+func TestSetCommit(t *testing.T) {
+	SetCommit("abc123")
+	t.Cleanup(func() { SetCommit("") })
+	l, buf := newBufferLogger(t, option.WithCommitField())
+
+	l.Info("built")
+
+	if entry := decodeLine(t, buf.String()); entry[commitKey] != "abc123" {
+		t.Errorf("got commit %v, want abc123", entry[commitKey])
+	}
+}
+
+func TestCommitFromBuildInfo(t *testing.T) {
+	prev := readBuildInfo
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "def456"}}}, true
+	}
+	t.Cleanup(func() { readBuildInfo = prev })
+	l, buf := newBufferLogger(t, option.WithCommitField())
+
+	l.Info("built")
+
+	if entry := decodeLine(t, buf.String()); entry[commitKey] != "def456" {
+		t.Errorf("got commit %v, want def456", entry[commitKey])
+	}
+}
@@ -0,0 +1,38 @@
+package easylog
+
+// LoggerConfig is the configuration a logger was built with.
+type LoggerConfig struct {
+	// Level is the lowest level the logger writes, following SetLevel.
+	Level string `json:"level"`
+	// Encoding is the encoding of the entries, "json", "console" or "csv".
+	Encoding string `json:"encoding"`
+	// CallerSkip is the number of frames skipped to report the caller of
+	// the package-level functions.
+	CallerSkip int `json:"caller_skip"`
+	// FilePath is the log file, empty if the logger writes no file.
+	FilePath string `json:"file_path,omitempty"`
+	// ThroughputLimit and ThroughputBurst are the lines per second and
+	// burst allowed through the logger, 0 if unlimited.
+	ThroughputLimit int `json:"throughput_limit"`
+	ThroughputBurst int `json:"throughput_burst"`
+	// TraceSampledGatingLevel is the level below which logs of unsampled
+	// traces are dropped, empty if disabled.
+	TraceSampledGatingLevel string `json:"trace_sampled_gating_level,omitempty"`
+	// Sampling reports whether entries may be dropped by the throughput
+	// limit, the trace sampled gating or the deadline aware sampling.
+	Sampling bool `json:"sampling"`
+	// Sinks names the outputs, see LoggerDiagnostics.
+	Sinks []string `json:"sinks"`
+}
+
+// Config returns the configuration l was built with by InitLogger or
+// InitGlobalLogger, or the zero config for other loggers.
+func (l *logger) Config() LoggerConfig {
+	if l.config == nil {
+		return LoggerConfig{}
+	}
+	c := *l.config
+	c.Level = l.Level()
+	c.Sinks = append([]string(nil), c.Sinks...)
+	return c
+}
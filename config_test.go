@@ -0,0 +1,51 @@
+package easylog
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/logerror/easylog/pkg/option"
+)
+
+func TestConfig(t *testing.T) {
+	setup(t)
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	l := InitLogger(
+		option.WithLogLevel("warn"),
+		option.WithEncoding("console"),
+		option.WithConsole(false),
+		option.WithLogFilePath(path),
+		option.WithThroughputLimit(100, 10),
+	)
+	defer l.Sync()
+
+	want := LoggerConfig{
+		Level:           "warn",
+		Encoding:        "console",
+		CallerSkip:      option.CallerSkip,
+		FilePath:        path,
+		ThroughputLimit: 100,
+		ThroughputBurst: 10,
+		Sampling:        true,
+		Sinks:           []string{"file:" + path},
+	}
+	got := l.Config()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	got.Sinks[0] = "changed"
+	if l.Config().Sinks[0] != "file:"+path {
+		t.Error("got the sinks of the logger changed through its config")
+	}
+}
+
+func TestConfigWithoutSampling(t *testing.T) {
+	l, _ := newBufferLogger(t)
+
+	if l.Config().Sampling {
+		t.Error("got sampling without any sampling option")
+	}
+}
@@ -0,0 +1,50 @@
+package easylog
+
+import (
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// consoleEncoder renders the fields meant to be read by humans, such as
+// Bytes, in their human-readable form, which the console encoder of zap
+// cannot do since it encodes reflected fields as JSON.
+type consoleEncoder struct {
+	zapcore.Encoder
+}
+
+func newConsoleEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	return &consoleEncoder{Encoder: zapcore.NewConsoleEncoder(cfg)}
+}
+
+func (e *consoleEncoder) Clone() zapcore.Encoder {
+	return &consoleEncoder{Encoder: e.Encoder.Clone()}
+}
+
+// AddReflected handles the fields added with With.
+func (e *consoleEncoder) AddReflected(key string, value interface{}) error {
+	if b, ok := value.(byteSize); ok {
+		e.Encoder.AddString(key, b.String())
+		return nil
+	}
+	return e.Encoder.AddReflected(key, value)
+}
+
+func (e *consoleEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	var rendered []zapcore.Field
+	for i, f := range fields {
+		b, ok := f.Interface.(byteSize)
+		if !ok || f.Type != zapcore.ReflectType {
+			continue
+		}
+		if rendered == nil {
+			// the fields are the caller's
+			rendered = make([]zapcore.Field, len(fields))
+			copy(rendered, fields)
+		}
+		rendered[i] = zapcore.Field{Key: f.Key, Type: zapcore.StringType, String: b.String()}
+	}
+	if rendered != nil {
+		fields = rendered
+	}
+	return e.Encoder.EncodeEntry(ent, fields)
+}
@@ -0,0 +1,628 @@
+package easylog
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	otelzap "github.com/logerror/easylog/pkg/otel"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const levelMarkerKey = "easylog.level"
+
+// levelMarker returns a field that is never encoded but makes the levelCore
+// of a logger derived with it enforce level.
+func levelMarker(level zapcore.Level) zapcore.Field {
+	return zapcore.Field{Key: levelMarkerKey, Type: zapcore.SkipType, Integer: int64(level)}
+}
+
+// levelCore enforces the level of a logger in front of a core enabled for
+// every level. Deriving it with a level marker replaces its level.
+type levelCore struct {
+	zapcore.Core
+	level zapcore.LevelEnabler
+}
+
+func newLevelCore(core zapcore.Core, level zapcore.LevelEnabler) zapcore.Core {
+	return &levelCore{
+		Core:  core,
+		level: level,
+	}
+}
+
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return c.level.Enabled(lvl)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	level := c.level
+	for _, f := range fields {
+		if f.Type == zapcore.SkipType && f.Key == levelMarkerKey {
+			level = zapcore.Level(f.Integer)
+		}
+	}
+	return &levelCore{
+		Core:  c.Core.With(fields),
+		level: level,
+	}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return c.Core.Check(ent, ce)
+	}
+	return ce
+}
+
+// leveledWriteCore enforces the level of the wrapped core on Write, for cores
+// teed beneath the wrappers that write entries without checking them first.
+type leveledWriteCore struct {
+	zapcore.Core
+}
+
+func newLeveledWriteCore(core zapcore.Core) zapcore.Core {
+	return &leveledWriteCore{Core: core}
+}
+
+func (c *leveledWriteCore) With(fields []zapcore.Field) zapcore.Core {
+	return &leveledWriteCore{Core: c.Core.With(fields)}
+}
+
+func (c *leveledWriteCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *leveledWriteCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if !c.Enabled(ent.Level) {
+		return nil
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// interceptorCore passes the fields of every entry through intercept before
+// handing them to the wrapped core.
+type interceptorCore struct {
+	zapcore.Core
+	intercept func(zapcore.Entry, []zapcore.Field) []zapcore.Field
+}
+
+func newInterceptorCore(core zapcore.Core, intercept func(zapcore.Entry, []zapcore.Field) []zapcore.Field) zapcore.Core {
+	return &interceptorCore{
+		Core:      core,
+		intercept: intercept,
+	}
+}
+
+func (c *interceptorCore) With(fields []zapcore.Field) zapcore.Core {
+	return &interceptorCore{
+		Core:      c.Core.With(fields),
+		intercept: c.intercept,
+	}
+}
+
+func (c *interceptorCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *interceptorCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.intercept(ent, fields))
+}
+
+// otelSeverity maps zap levels to OpenTelemetry severity numbers.
+var otelSeverity = map[zapcore.Level]struct {
+	text   string
+	number int64
+}{
+	zapcore.DebugLevel:  {"DEBUG", 5},
+	zapcore.InfoLevel:   {"INFO", 9},
+	zapcore.WarnLevel:   {"WARN", 13},
+	zapcore.ErrorLevel:  {"ERROR", 17},
+	zapcore.DPanicLevel: {"ERROR", 18},
+	zapcore.PanicLevel:  {"ERROR", 19},
+	zapcore.FatalLevel:  {"FATAL", 21},
+}
+
+// appendOtelSeverity adds the OpenTelemetry severity_text and
+// severity_number of the entry level to fields.
+func appendOtelSeverity(ent zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
+	severity, ok := otelSeverity[ent.Level]
+	if !ok {
+		return fields
+	}
+	return append(fields[:len(fields):len(fields)],
+		zap.String("severity_text", severity.text),
+		zap.Int64("severity_number", severity.number),
+	)
+}
+
+// emptyMessageCore drops entries with an empty message or rewrites them to
+// a fixed level before they reach the wrapped core.
+type emptyMessageCore struct {
+	zapcore.Core
+	drop  bool
+	level zapcore.Level
+}
+
+func newEmptyMessageCore(core zapcore.Core, drop bool, level zapcore.Level) zapcore.Core {
+	return &emptyMessageCore{
+		Core:  core,
+		drop:  drop,
+		level: level,
+	}
+}
+
+func (c *emptyMessageCore) With(fields []zapcore.Field) zapcore.Core {
+	return &emptyMessageCore{
+		Core:  c.Core.With(fields),
+		drop:  c.drop,
+		level: c.level,
+	}
+}
+
+func (c *emptyMessageCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Message == "" {
+		if c.drop {
+			return ce
+		}
+		ent.Level = c.level
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// throughputDropped counts the entries dropped by all throughput limits.
+var throughputDropped uint64
+
+// ThroughputDropped returns the number of entries dropped so far because
+// they exceeded the throughput limit.
+func ThroughputDropped() uint64 {
+	return atomic.LoadUint64(&throughputDropped)
+}
+
+// tokenBucket refills rate tokens per second up to burst.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// throughputCore drops entries once its token bucket, shared with all cores
+// derived from it, runs empty.
+type throughputCore struct {
+	zapcore.Core
+	bucket       *tokenBucket
+	bypassErrors bool
+}
+
+func newThroughputCore(core zapcore.Core, linesPerSecond, burst int, bypassErrors bool) zapcore.Core {
+	if burst < 1 {
+		burst = 1
+	}
+	return &throughputCore{
+		Core: core,
+		bucket: &tokenBucket{
+			rate:   float64(linesPerSecond),
+			burst:  float64(burst),
+			tokens: float64(burst),
+			last:   time.Now(),
+		},
+		bypassErrors: bypassErrors,
+	}
+}
+
+func (c *throughputCore) With(fields []zapcore.Field) zapcore.Core {
+	return &throughputCore{
+		Core:         c.Core.With(fields),
+		bucket:       c.bucket,
+		bypassErrors: c.bypassErrors,
+	}
+}
+
+func (c *throughputCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	if !(c.bypassErrors && ent.Level >= zapcore.ErrorLevel) && !c.bucket.allow() {
+		atomic.AddUint64(&throughputDropped, 1)
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// sampledGatingCore drops entries below minLevel once it has been derived
+// with the fields of an unsampled trace.
+type sampledGatingCore struct {
+	zapcore.Core
+	minLevel  zapcore.Level
+	unsampled bool
+}
+
+func newSampledGatingCore(core zapcore.Core, minLevel zapcore.Level) zapcore.Core {
+	return &sampledGatingCore{
+		Core:     core,
+		minLevel: minLevel,
+	}
+}
+
+func (c *sampledGatingCore) With(fields []zapcore.Field) zapcore.Core {
+	unsampled := c.unsampled
+	if sampled, ok := otelzap.SampledFromFields(fields); ok {
+		unsampled = !sampled
+	}
+	return &sampledGatingCore{
+		Core:      c.Core.With(fields),
+		minLevel:  c.minLevel,
+		unsampled: unsampled,
+	}
+}
+
+func (c *sampledGatingCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.unsampled && ent.Level < c.minLevel {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// omitEmptyMessageCore writes entries with an empty message through noMessage,
+// a core encoding without the message key. It routes on Write, as the
+// wrappers above it write entries without checking them first.
+type omitEmptyMessageCore struct {
+	zapcore.Core
+	noMessage zapcore.Core
+}
+
+func newOmitEmptyMessageCore(core, noMessage zapcore.Core) zapcore.Core {
+	return &omitEmptyMessageCore{
+		Core:      core,
+		noMessage: noMessage,
+	}
+}
+
+func (c *omitEmptyMessageCore) With(fields []zapcore.Field) zapcore.Core {
+	return &omitEmptyMessageCore{
+		Core:      c.Core.With(fields),
+		noMessage: c.noMessage.With(fields),
+	}
+}
+
+func (c *omitEmptyMessageCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *omitEmptyMessageCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ent.Message == "" {
+		return c.noMessage.Write(ent, fields)
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// appendUptime returns an interceptor adding the milliseconds elapsed since
+// start, measured on the monotonic clock, as the key field.
+func appendUptime(key string, start time.Time) func(zapcore.Entry, []zapcore.Field) []zapcore.Field {
+	return func(ent zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
+		return append(fields[:len(fields):len(fields)], zap.Int64(key, ent.Time.Sub(start).Milliseconds()))
+	}
+}
+
+// appendPackage is an interceptor adding the import path of the package of
+// the entry caller as the pkg field.
+func appendPackage(ent zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
+	pkg := callerPackage(ent.Caller)
+	if pkg == "" {
+		return fields
+	}
+	return append(fields[:len(fields):len(fields)], zap.String("pkg", pkg))
+}
+
+// callerPackage returns the import path of the package of caller, resolving
+// the function from the PC if zap did not record it.
+func callerPackage(caller zapcore.EntryCaller) string {
+	if !caller.Defined {
+		return ""
+	}
+	function := caller.Function
+	if function == "" {
+		fn := runtime.FuncForPC(caller.PC)
+		if fn == nil {
+			return ""
+		}
+		function = fn.Name()
+	}
+	// the package path ends at the first dot after the last slash, e.g.
+	// github.com/logerror/easylog.(*logger).Info
+	lastSlash := strings.LastIndexByte(function, '/')
+	if dot := strings.IndexByte(function[lastSlash+1:], '.'); dot >= 0 {
+		return function[:lastSlash+1+dot]
+	}
+	return function
+}
+
+// appendOSThread is an interceptor adding the id of the OS thread writing
+// the entry as the tid field, if available.
+func appendOSThread(ent zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
+	tid, ok := osThreadID()
+	if !ok {
+		return fields
+	}
+	return append(fields[:len(fields):len(fields)], zap.Int("tid", tid))
+}
+
+// truncateFields returns an interceptor keeping the first max fields of an
+// entry and adding the number of the others as the fields_truncated field.
+// It runs before the other interceptors, so the fields they add are kept.
+func truncateFields(max int) func(zapcore.Entry, []zapcore.Field) []zapcore.Field {
+	return func(ent zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
+		if len(fields) <= max {
+			return fields
+		}
+		return append(fields[:max:max], zap.Int("fields_truncated", len(fields)-max))
+	}
+}
+
+// structuredStackCore replaces the stacktrace string of an entry with a stack
+// field holding one "function file:line" element per frame.
+type structuredStackCore struct {
+	zapcore.Core
+}
+
+func newStructuredStackCore(core zapcore.Core) zapcore.Core {
+	return &structuredStackCore{Core: core}
+}
+
+func (c *structuredStackCore) With(fields []zapcore.Field) zapcore.Core {
+	return &structuredStackCore{Core: c.Core.With(fields)}
+}
+
+func (c *structuredStackCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *structuredStackCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ent.Stack != "" {
+		fields = append(fields[:len(fields):len(fields)], zap.Strings("stack", stackFrames(ent.Stack)))
+		ent.Stack = ""
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// stackFrames splits a zap stacktrace, made of a function line followed by a
+// tab-indented file:line line per frame, into one element per frame.
+func stackFrames(stack string) []string {
+	lines := strings.Split(stack, "\n")
+	frames := make([]string, 0, len(lines)/2)
+	for i := 0; i < len(lines); i += 2 {
+		frame := lines[i]
+		if i+1 < len(lines) {
+			frame += " " + strings.TrimPrefix(lines[i+1], "\t")
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// levelField wraps a field that is only written for entries at or above
+// minLevel. It is carried by a SkipType field, so cores not resolving it
+// never write it.
+type levelField struct {
+	minLevel zapcore.Level
+	field    zapcore.Field
+}
+
+// levelFieldCore resolves the fields created by LevelField against the level
+// of each entry.
+type levelFieldCore struct {
+	zapcore.Core
+	// fields are the level fields the core was derived with
+	fields []zapcore.Field
+}
+
+func newLevelFieldCore(core zapcore.Core) zapcore.Core {
+	return &levelFieldCore{Core: core}
+}
+
+func (c *levelFieldCore) With(fields []zapcore.Field) zapcore.Core {
+	var plain, leveled []zapcore.Field
+	for _, f := range fields {
+		if _, ok := f.Interface.(levelField); ok && f.Type == zapcore.SkipType {
+			leveled = append(leveled, f)
+		} else {
+			plain = append(plain, f)
+		}
+	}
+	return &levelFieldCore{
+		Core:   c.Core.With(plain),
+		fields: append(c.fields[:len(c.fields):len(c.fields)], leveled...),
+	}
+}
+
+func (c *levelFieldCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *levelFieldCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	resolved := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	for _, f := range append(c.fields[:len(c.fields):len(c.fields)], fields...) {
+		lf, ok := f.Interface.(levelField)
+		if !ok || f.Type != zapcore.SkipType {
+			resolved = append(resolved, f)
+		} else if ent.Level >= lf.minLevel {
+			resolved = append(resolved, lf.field)
+		}
+	}
+	return c.Core.Write(ent, resolved)
+}
+
+// safeCore recovers from panics raised while encoding fields, replacing the
+// offending fields with an encode_error field.
+type safeCore struct {
+	zapcore.Core
+}
+
+func newSafeCore(core zapcore.Core) zapcore.Core {
+	return &safeCore{Core: core}
+}
+
+func (c *safeCore) With(fields []zapcore.Field) (core zapcore.Core) {
+	defer func() {
+		if r := recover(); r != nil {
+			core = &safeCore{Core: c.Core.With([]zapcore.Field{encodeError(r)})}
+		}
+	}()
+	return &safeCore{Core: c.Core.With(fields)}
+}
+
+func (c *safeCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *safeCore) Write(ent zapcore.Entry, fields []zapcore.Field) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = c.Core.Write(ent, []zapcore.Field{encodeError(r)})
+		}
+	}()
+	return c.Core.Write(ent, fields)
+}
+
+func encodeError(r interface{}) zapcore.Field {
+	return zap.String("encode_error", fmt.Sprint(r))
+}
+
+type samplerKey struct {
+	level   zapcore.Level
+	message string
+}
+
+// samplerState counts the entries per level and message of a sampler and all
+// cores derived from it.
+type samplerState struct {
+	mu         sync.Mutex
+	tick       time.Duration
+	initial    uint64
+	thereafter uint64
+	levelOf    func() zapcore.Level
+	lastLevel  zapcore.Level
+	resetAt    time.Time
+	counts     map[samplerKey]uint64
+
+	// passUntil is the end of the tick following a level change, during
+	// which every entry is logged.
+	passUntil time.Time
+}
+
+func (s *samplerState) reset(now time.Time) {
+	s.resetAt = now
+	s.counts = make(map[samplerKey]uint64)
+}
+
+// sample reports whether ent is logged. Counts restart every tick. When the
+// level reported by levelOf changes, every entry is logged for a tick, so
+// that operators raising the level see everything, and counts restart after.
+func (s *samplerState) sample(ent zapcore.Entry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.levelOf != nil {
+		if lvl := s.levelOf(); lvl != s.lastLevel {
+			s.lastLevel = lvl
+			s.reset(ent.Time)
+			s.passUntil = ent.Time.Add(s.tick)
+		}
+	}
+	if ent.Time.Before(s.passUntil) {
+		return true
+	}
+	if ent.Time.Sub(s.resetAt) >= s.tick {
+		s.reset(ent.Time)
+	}
+
+	key := samplerKey{level: ent.Level, message: ent.Message}
+	n := s.counts[key] + 1
+	s.counts[key] = n
+	if n <= s.initial {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.initial)%s.thereafter == 0
+}
+
+// samplerCore logs the first initial entries with a given level and message
+// per tick and every thereafter-th entry after that, like zap's sampler, but
+// also logs every entry for a tick when the level reported by levelOf
+// changes.
+type samplerCore struct {
+	zapcore.Core
+	state *samplerState
+}
+
+func newSamplerCore(core zapcore.Core, tick time.Duration, initial, thereafter int, levelOf func() zapcore.Level) zapcore.Core {
+	state := &samplerState{
+		tick:       tick,
+		initial:    uint64(initial),
+		thereafter: uint64(thereafter),
+		levelOf:    levelOf,
+	}
+	if levelOf != nil {
+		state.lastLevel = levelOf()
+	}
+	state.reset(time.Now())
+	return &samplerCore{
+		Core:  core,
+		state: state,
+	}
+}
+
+func (c *samplerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &samplerCore{
+		Core:  c.Core.With(fields),
+		state: c.state,
+	}
+}
+
+func (c *samplerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) || !c.state.sample(ent) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,191 @@
+package easylog
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/logerror/easylog/pkg/option"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithThroughputLimit(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithThroughputLimit(10, 100))
+	dropped := ThroughputDropped()
+
+	for i := 0; i < 10000; i++ {
+		l.Info("burst")
+	}
+
+	// the burst plus what the bucket refills while logging
+	if n := strings.Count(buf.String(), "\n"); n < 100 || n > 200 {
+		t.Errorf("got %d lines, want about 100", n)
+	}
+	if d := ThroughputDropped() - dropped; d < 9800 {
+		t.Errorf("got %d entries dropped, want at least 9800", d)
+	}
+}
+
+func TestWithThroughputErrorBypass(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithThroughputLimit(1, 1), option.WithThroughputErrorBypass(true))
+
+	for i := 0; i < 100; i++ {
+		l.Error("bypassed")
+	}
+
+	if n := strings.Count(buf.String(), "\n"); n != 100 {
+		t.Errorf("got %d lines, want 100", n)
+	}
+}
+
+func TestWithTraceSampledGating(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithTraceSampledGating("warn"))
+	spanContext := func(flags trace.TraceFlags) context.Context {
+		return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    trace.TraceID{1},
+			SpanID:     trace.SpanID{1},
+			TraceFlags: flags,
+		}))
+	}
+	sampled, unsampled := spanContext(trace.FlagsSampled), spanContext(0)
+
+	l.WithContext(sampled).Info("sampled info")
+	l.WithContext(unsampled).Info("unsampled info")
+	l.WithContext(unsampled).Warn("unsampled warn")
+	l.Info("untraced info")
+
+	var msgs []interface{}
+	for _, entry := range decodeLines(t, buf.String()) {
+		msgs = append(msgs, entry["msg"])
+	}
+	want := []interface{}{"sampled info", "unsampled warn", "untraced info"}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Errorf("got messages %v, want %v", msgs, want)
+	}
+}
+
+func TestWithOtelSeverity(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithOtelSeverity(true))
+
+	l.Error("failed")
+
+	entry := decodeLine(t, buf.String())
+	if entry["severity_text"] != "ERROR" || entry["severity_number"] != 17.0 {
+		t.Errorf("got severity %v/%v, want ERROR/17", entry["severity_text"], entry["severity_number"])
+	}
+}
+
+func TestWithStructuredStack(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithStructuredStack(true))
+
+	l.Error("failed")
+
+	entry := decodeLine(t, buf.String())
+	if _, ok := entry["stacktrace"]; ok {
+		t.Errorf("got stacktrace %v, want none", entry["stacktrace"])
+	}
+	frames, ok := entry["stack"].([]interface{})
+	if !ok || len(frames) == 0 || !strings.Contains(frames[0].(string), "TestWithStructuredStack") {
+		t.Errorf("got stack %v, want frames starting with the test function", entry["stack"])
+	}
+}
+
+func TestLevelField(t *testing.T) {
+	l, buf := newBufferLogger(t)
+	dump := LevelField(ErrorLevel, zap.String("dump", "GET /users"))
+
+	l.Info("info", dump)
+	l.Error("error", dump)
+	l.With(dump).Info("with info")
+	l.With(dump).Error("with error")
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 4 {
+		t.Fatalf("got %d lines, want 4", len(entries))
+	}
+	for i, want := range []interface{}{nil, "GET /users", nil, "GET /users"} {
+		if got := entries[i]["dump"]; got != want {
+			t.Errorf("%v: got dump %v, want %v", entries[i]["msg"], got, want)
+		}
+	}
+}
+
+// panickingMarshaler panics when encoded, like a typed-nil marshaler.
+type panickingMarshaler struct{}
+
+func (panickingMarshaler) MarshalLogObject(zapcore.ObjectEncoder) error {
+	panic("nil marshaler")
+}
+
+func TestWithSafeEncoding(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithSafeEncoding(true))
+
+	l.Info("survived", zap.Object("obj", panickingMarshaler{}))
+	l.With(zap.Object("obj", panickingMarshaler{})).Info("survived too")
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 2 {
+		t.Fatalf("got %d lines, want 2", len(entries))
+	}
+	for _, entry := range entries {
+		if entry["encode_error"] != "nil marshaler" {
+			t.Errorf("%v: got encode_error %v, want nil marshaler", entry["msg"], entry["encode_error"])
+		}
+	}
+}
+
+func TestWithUptimeField(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithUptimeField(""))
+
+	l.Info("first")
+	time.Sleep(5 * time.Millisecond)
+	l.Info("second")
+
+	entries := decodeLines(t, buf.String())
+	first, ok1 := entries[0]["uptime_ms"].(float64)
+	second, ok2 := entries[1]["uptime_ms"].(float64)
+	if !ok1 || !ok2 || second <= first {
+		t.Errorf("got uptime_ms %v then %v, want increasing values", entries[0]["uptime_ms"], entries[1]["uptime_ms"])
+	}
+}
+
+func TestWithAutoPackageField(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithAutoPackageField())
+
+	l.Info("here")
+	// logs from another package, the test package of easylog
+	LogFromExternalPackage(l)
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 2 {
+		t.Fatalf("got %d lines, want 2", len(entries))
+	}
+	for i, want := range []string{"github.com/logerror/easylog", "github.com/logerror/easylog_test"} {
+		if entries[i]["pkg"] != want {
+			t.Errorf("%v: got pkg %v, want %s", entries[i]["msg"], entries[i]["pkg"], want)
+		}
+	}
+}
+
+func TestWithMaxFields(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithMaxFields(10))
+	fields := make([]Field, 50)
+	for i := range fields {
+		fields[i] = zap.Int(fmt.Sprintf("f%02d", i), i)
+	}
+
+	l.Info("bloated", fields...)
+
+	entry := decodeLine(t, buf.String())
+	if entry["fields_truncated"] != 40.0 {
+		t.Errorf("got fields_truncated %v, want 40", entry["fields_truncated"])
+	}
+	if entry["f09"] != 9.0 || entry["f10"] != nil {
+		t.Errorf("got f09=%v and f10=%v, want the first 10 fields only", entry["f09"], entry["f10"])
+	}
+}
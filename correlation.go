@@ -0,0 +1,46 @@
+package easylog
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const correlationIdKey = "correlation_id"
+
+type correlationIdContextKey struct{}
+
+// EnsureCorrelationID returns ctx carrying a correlation id along with the
+// id. An id already in ctx is kept, otherwise the trace id of the span in
+// ctx is reused, falling back to a newly generated UUID.
+func EnsureCorrelationID(ctx context.Context) (context.Context, string) {
+	if id, ok := CorrelationID(ctx); ok {
+		return ctx, id
+	}
+
+	var id string
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		id = spanContext.TraceID().String()
+	} else {
+		id = newUUID()
+	}
+	return context.WithValue(ctx, correlationIdContextKey{}, id), id
+}
+
+// CorrelationID returns the correlation id stored in ctx by
+// EnsureCorrelationID.
+func CorrelationID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(correlationIdContextKey{}).(string)
+	return id, ok
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
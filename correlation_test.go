@@ -0,0 +1,41 @@
+package easylog
+
+import (
+	"context"
+	"testing"
+)
+
+func TestEnsureCorrelationID(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+
+	ctx, id := EnsureCorrelationID(context.Background())
+	if len(id) != 36 {
+		t.Fatalf("got id %q, want a UUID", id)
+	}
+	if _, again := EnsureCorrelationID(ctx); again != id {
+		t.Errorf("got id %q on second call, want %q", again, id)
+	}
+	G(ctx).Info("first")
+	GS(ctx).Info("second")
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 2 {
+		t.Fatalf("got %d lines, want 2", len(entries))
+	}
+	for _, entry := range entries {
+		if entry[correlationIdKey] != id {
+			t.Errorf("%v: got correlation_id %v, want %s", entry["msg"], entry[correlationIdKey], id)
+		}
+	}
+}
+
+func TestEnsureCorrelationIDReusesTraceID(t *testing.T) {
+	setup(t)
+	tracer, _ := newTracer(t)
+	ctx, span := tracer.Start(context.Background(), "op")
+	defer span.End()
+
+	if _, id := EnsureCorrelationID(ctx); id != span.SpanContext().TraceID().String() {
+		t.Errorf("got id %q, want the trace id", id)
+	}
+}
@@ -0,0 +1,121 @@
+package easylog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"sync"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var csvPool = buffer.NewPool()
+
+// csvEncoder encodes every entry as a CSV row holding the values of the
+// fields named by its columns, blank for the fields an entry lacks. Values
+// that are JSON strings are unquoted, the others keep their JSON encoding,
+// e.g. {"a":1} for an object. The header row is written by csvHeaderSyncer,
+// once per output rather than once per encoder.
+type csvEncoder struct {
+	// the entries are encoded in JSON first to read their fields by key,
+	// which are the keys of the encoder config
+	zapcore.Encoder
+	columns []string
+}
+
+func newCSVEncoder(cfg zapcore.EncoderConfig, columns []string) zapcore.Encoder {
+	return &csvEncoder{
+		Encoder: zapcore.NewJSONEncoder(cfg),
+		columns: columns,
+	}
+}
+
+func (e *csvEncoder) Clone() zapcore.Encoder {
+	return &csvEncoder{
+		Encoder: e.Encoder.Clone(),
+		columns: e.columns,
+	}
+}
+
+func (e *csvEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]json.RawMessage
+	err = json.Unmarshal(buf.Bytes(), &values)
+	buf.Free()
+	if err != nil {
+		return nil, err
+	}
+
+	out := csvPool.Get()
+	w := csv.NewWriter(out)
+	row := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		row[i] = csvValue(values[column])
+	}
+	_ = w.Write(row)
+	w.Flush()
+	if err := w.Error(); err != nil {
+		out.Free()
+		return nil, err
+	}
+	return out, nil
+}
+
+// csvHeaderSyncer writes a header row naming the columns before the first
+// row written to the wrapped syncer. All the cores writing CSV to the same
+// output share it, so that the header is written once and before any row.
+type csvHeaderSyncer struct {
+	zapcore.WriteSyncer
+	header []byte
+
+	mu          sync.Mutex
+	wroteHeader bool
+}
+
+// newCSVHeaderSyncer returns ws writing the header of columns first, or ws
+// itself if the entries are not encoded as CSV.
+func newCSVHeaderSyncer(ws zapcore.WriteSyncer, columns []string) zapcore.WriteSyncer {
+	if encoding() != "csv" {
+		return ws
+	}
+	var header bytes.Buffer
+	w := csv.NewWriter(&header)
+	_ = w.Write(columns)
+	w.Flush()
+	return &csvHeaderSyncer{
+		WriteSyncer: ws,
+		header:      header.Bytes(),
+	}
+}
+
+func (s *csvHeaderSyncer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.wroteHeader {
+		return s.WriteSyncer.Write(p)
+	}
+	// the header and the first row are written at once, a failed write is
+	// retried with the next row
+	if _, err := s.WriteSyncer.Write(append(s.header[:len(s.header):len(s.header)], p...)); err != nil {
+		return 0, err
+	}
+	s.wroteHeader = true
+	return len(p), nil
+}
+
+// csvValue returns the CSV value of the JSON value raw.
+func csvValue(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if raw[0] == '"' && json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return string(raw)
+}
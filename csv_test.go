@@ -0,0 +1,44 @@
+package easylog
+
+import (
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/logerror/easylog/pkg/option"
+	"go.uber.org/zap"
+)
+
+func TestWithCSVEncoding(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithCSVEncoding("level", "msg", "user_id"))
+
+	l.Info("signed in", zap.String("user_id", "u-42"))
+	l.Warn(`quota "soft", exceeded`)
+
+	rows, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("got invalid CSV %q: %v", buf.String(), err)
+	}
+	want := [][]string{
+		{"level", "msg", "user_id"},
+		{"info", "signed in", "u-42"},
+		{"warn", `quota "soft", exceeded`, ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("got %q, want %q", rows, want)
+	}
+}
+
+func TestWithCSVEncodingOmitEmptyMessage(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithCSVEncoding("level", "msg"), option.WithOmitEmptyMessage(true))
+
+	l.Info("a")
+	l.Info("")
+	l.Info("b")
+
+	want := "level,msg\ninfo,a\ninfo,\ninfo,b\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
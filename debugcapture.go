@@ -0,0 +1,167 @@
+package easylog
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	// debugCapture holds the *debugCaptureState of the running capture, nil
+	// when no capture is running.
+	debugCapture   atomic.Value
+	debugCaptureMu sync.Mutex
+)
+
+// debugCaptureState is a capture started by StartDebugCapture.
+type debugCaptureState struct {
+	core zapcore.Core
+	file *debugCaptureFile
+}
+
+// debugCaptureFile is the file of a capture. Closing it waits for the
+// entries being written, and the entries routed to the capture before it
+// stopped but written after are dropped rather than failing.
+type debugCaptureFile struct {
+	mu     sync.Mutex
+	file   *os.File
+	closed bool
+}
+
+func (f *debugCaptureFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return len(p), nil
+	}
+	return f.file.Write(p)
+}
+
+func (f *debugCaptureFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+func (f *debugCaptureFile) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+	_ = f.file.Sync()
+	_ = f.file.Close()
+}
+
+// StartDebugCapture writes every entry at any level, regardless of the level
+// of the logger, to the file at path in addition to the regular output. The
+// capture stops by itself after d. Only one capture can run at a time.
+func StartDebugCapture(path string, d time.Duration) error {
+	debugCaptureMu.Lock()
+	defer debugCaptureMu.Unlock()
+
+	if loadDebugCapture() != nil {
+		return errors.New("easylog: a debug capture is already running")
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	file := &debugCaptureFile{file: f}
+	state := &debugCaptureState{
+		core: newLevelFieldCore(zapcore.NewCore(
+			zapcore.NewJSONEncoder(newEncoderConfig()),
+			file,
+			zapcore.DebugLevel,
+		)),
+		file: file,
+	}
+	debugCapture.Store(state)
+	time.AfterFunc(d, func() {
+		stopDebugCapture(state)
+	})
+	return nil
+}
+
+func loadDebugCapture() *debugCaptureState {
+	state, _ := debugCapture.Load().(*debugCaptureState)
+	return state
+}
+
+func stopDebugCapture(state *debugCaptureState) {
+	debugCaptureMu.Lock()
+	defer debugCaptureMu.Unlock()
+
+	if loadDebugCapture() != state {
+		return
+	}
+	debugCapture.Store((*debugCaptureState)(nil))
+	state.file.close()
+}
+
+// debugCaptureCore additionally writes every entry to the running debug
+// capture, if any. It wraps all other cores so that entries the logger
+// would drop are captured as well.
+type debugCaptureCore struct {
+	zapcore.Core
+	// fields are the fields the core was derived with, added to the
+	// capture core once per capture
+	fields []zapcore.Field
+	// capture holds the *derivedCapture of the last capture an entry was
+	// checked in
+	capture atomic.Value
+}
+
+// derivedCapture is the core of a capture derived with the fields of a
+// debugCaptureCore.
+type derivedCapture struct {
+	state *debugCaptureState
+	core  zapcore.Core
+}
+
+func newDebugCaptureCore(core zapcore.Core) zapcore.Core {
+	return &debugCaptureCore{Core: core}
+}
+
+func (c *debugCaptureCore) Enabled(lvl zapcore.Level) bool {
+	return loadDebugCapture() != nil || c.Core.Enabled(lvl)
+}
+
+func (c *debugCaptureCore) With(fields []zapcore.Field) zapcore.Core {
+	return &debugCaptureCore{
+		Core:   c.Core.With(fields),
+		fields: append(c.fields[:len(c.fields):len(c.fields)], fields...),
+	}
+}
+
+func (c *debugCaptureCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	ce = c.Core.Check(ent, ce)
+	if state := loadDebugCapture(); state != nil {
+		ce = c.captureCore(state).Check(ent, ce)
+	}
+	return ce
+}
+
+// captureCore returns the core of state with the fields of c, deriving it on
+// the first entry of each capture only.
+func (c *debugCaptureCore) captureCore(state *debugCaptureState) zapcore.Core {
+	if len(c.fields) == 0 {
+		return state.core
+	}
+	if d, _ := c.capture.Load().(*derivedCapture); d != nil && d.state == state {
+		return d.core
+	}
+	d := &derivedCapture{state: state, core: state.core.With(c.fields)}
+	c.capture.Store(d)
+	return d.core
+}
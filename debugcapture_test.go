@@ -0,0 +1,76 @@
+package easylog
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/logerror/easylog/pkg/option"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestStartDebugCapture(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithLogLevel("info"))
+	path := filepath.Join(t.TempDir(), "capture.log")
+
+	if err := StartDebugCapture(path, 100*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := StartDebugCapture(path, time.Second); err == nil {
+		t.Error("got no error starting a second capture")
+	}
+	l.With(zap.String("k", "v")).Debug("captured")
+	for loadDebugCapture() != nil {
+		time.Sleep(10 * time.Millisecond)
+	}
+	l.Debug("after the window")
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry := decodeLine(t, string(b)); entry["msg"] != "captured" || entry["k"] != "v" {
+		t.Errorf("got %v in the capture, want captured with k=v", entry)
+	}
+	if out := buf.String(); out != "" {
+		t.Errorf("got output %q, want the debug lines left out", out)
+	}
+}
+
+func TestStopDebugCaptureWhileWriting(t *testing.T) {
+	setup(t)
+	path := filepath.Join(t.TempDir(), "capture.log")
+	if err := StartDebugCapture(path, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	state := loadDebugCapture()
+
+	errOut := &syncBuffer{}
+	core := newDebugCaptureCore(zapcore.NewNopCore()).With([]zapcore.Field{zap.String("k", "v")})
+	if c := core.(*debugCaptureCore); c.captureCore(state) != c.captureCore(state) {
+		t.Error("got the capture core derived again for the same capture")
+	}
+	lg := zap.New(core, zap.ErrorOutput(zapcore.AddSync(errOut)))
+
+	routed := lg.Check(zap.DebugLevel, "routed")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				lg.Debug("concurrent")
+			}
+		}()
+	}
+	stopDebugCapture(state)
+	wg.Wait()
+	routed.Write()
+
+	if out := errOut.String(); out != "" {
+		t.Errorf("got write errors %q, want none", out)
+	}
+}
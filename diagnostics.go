@@ -0,0 +1,112 @@
+package easylog
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/logerror/easylog/pkg/option"
+	"go.uber.org/zap/zapcore"
+)
+
+// LoggerDiagnostics describes the state of the global logger.
+type LoggerDiagnostics struct {
+	// Level is the lowest level the global logger writes.
+	Level string `json:"level"`
+	// Sinks names the outputs, "console", "file:<path>", "crash:<path>"
+	// and "error:<path>".
+	Sinks []string `json:"sinks"`
+	// Sampling reports whether entries may be dropped by the throughput
+	// limit, the trace sampled gating or the deadline aware sampling.
+	Sampling bool `json:"sampling"`
+	// ThroughputLimit and ThroughputBurst are the configured lines per
+	// second and burst, 0 if unlimited.
+	ThroughputLimit int `json:"throughput_limit"`
+	ThroughputBurst int `json:"throughput_burst"`
+	// TraceSampledGatingLevel is the level below which logs of unsampled
+	// traces are dropped, empty if disabled.
+	TraceSampledGatingLevel string `json:"trace_sampled_gating_level,omitempty"`
+	// Dropped is the number of entries dropped by the throughput limit.
+	Dropped uint64 `json:"dropped"`
+	// LastSyncError is the last error writing or syncing a log file.
+	LastSyncError string `json:"last_sync_error,omitempty"`
+}
+
+// Diagnostics reports the state of the global logger, e.g. for health checks.
+func Diagnostics() LoggerDiagnostics {
+	d := LoggerDiagnostics{
+		Level:                   enabledLevel(globalLogger.CoreLogger().Core()).String(),
+		Sinks:                   append([]string(nil), globalRawLogger.sinks...),
+		Sampling:                sampling(),
+		ThroughputLimit:         option.ThroughputLimit,
+		ThroughputBurst:         option.ThroughputBurst,
+		TraceSampledGatingLevel: option.TraceSampledGatingLevel,
+		Dropped:                 ThroughputDropped(),
+	}
+	if err, ok := lastSyncError.Load().(string); ok {
+		d.LastSyncError = err
+	}
+	return d
+}
+
+// sampling reports whether the options drop entries by sampling them.
+func sampling() bool {
+	return option.ThroughputLimit > 0 || option.TraceSampledGatingLevel != "" || option.DeadlineSamplingRate > 1
+}
+
+// DiagnosticsHandler returns an http.Handler serving Diagnostics as JSON.
+func DiagnosticsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(Diagnostics())
+	})
+}
+
+// enabledLevel returns the lowest level enabled by core.
+func enabledLevel(core zapcore.Core) zapcore.Level {
+	for lvl := zapcore.DebugLevel; lvl < zapcore.FatalLevel; lvl++ {
+		if core.Enabled(lvl) {
+			return lvl
+		}
+	}
+	return zapcore.FatalLevel
+}
+
+// levelPayload is the body of the requests and responses of LevelHandler.
+type levelPayload struct {
+	Level string `json:"level,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// LevelHandler returns an http.Handler changing the level of the global
+// logger at runtime, like zap's AtomicLevel but with the level names of
+// SetLevelByName. GET responds with the level as {"level":"info"}, PUT and
+// POST set it from a body of the same form and respond with the new level.
+// Unknown levels are rejected with 400.
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var req levelPayload
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = enc.Encode(levelPayload{Error: "easylog: invalid body: " + err.Error()})
+				return
+			}
+			if err := SetLevelByName(req.Level); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_ = enc.Encode(levelPayload{Error: err.Error()})
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			_ = enc.Encode(levelPayload{Error: "easylog: only GET, PUT and POST are supported"})
+			return
+		}
+		_ = enc.Encode(levelPayload{Level: globalLoggerLevel.Level().String()})
+	})
+}
@@ -0,0 +1,79 @@
+package easylog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/logerror/easylog/pkg/option"
+)
+
+func TestDiagnostics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	initGlobalBufferLogger(t, option.WithLogLevel("warn"), option.WithLogFilePath(path), option.WithThroughputLimit(100, 10))
+
+	d := Diagnostics()
+	if d.Level != "warn" {
+		t.Errorf("got level %s, want warn", d.Level)
+	}
+	if len(d.Sinks) != 2 || d.Sinks[0] != "console" || d.Sinks[1] != "file:"+path {
+		t.Errorf("got sinks %v, want console and the file", d.Sinks)
+	}
+	if !d.Sampling || d.ThroughputLimit != 100 || d.ThroughputBurst != 10 {
+		t.Errorf("got sampling %v with limit %d and burst %d, want true, 100 and 10", d.Sampling, d.ThroughputLimit, d.ThroughputBurst)
+	}
+
+	d.Sinks[0] = "changed"
+	if Diagnostics().Sinks[0] != "console" {
+		t.Error("changing the sinks of the diagnostics changed those of the logger")
+	}
+}
+
+func TestDiagnosticsHandler(t *testing.T) {
+	initGlobalBufferLogger(t, option.WithLogLevel("error"))
+
+	rec := httptest.NewRecorder()
+	DiagnosticsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/diagnostics", nil))
+
+	var d LoggerDiagnostics
+	if err := json.NewDecoder(rec.Body).Decode(&d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Level != "error" || d.Sampling {
+		t.Errorf("got level %s and sampling %v, want error and false", d.Level, d.Sampling)
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	initGlobalBufferLogger(t, option.WithLogLevel("info"))
+	handler := LevelHandler()
+
+	serve := func(method, body string) (int, levelPayload) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, "/level", strings.NewReader(body)))
+		var p levelPayload
+		if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+			t.Fatalf("%s %s: %v", method, body, err)
+		}
+		return rec.Code, p
+	}
+
+	if code, p := serve("GET", ""); code != http.StatusOK || p.Level != "info" {
+		t.Errorf("GET: got %d %+v, want 200 and info", code, p)
+	}
+	if code, p := serve("PUT", `{"level":"verbose"}`); code != http.StatusBadRequest || p.Error == "" {
+		t.Errorf("PUT verbose: got %d %+v, want 400 and an error", code, p)
+	}
+	if code, p := serve("PUT", `{"level":"debug"}`); code != http.StatusOK || p.Level != "debug" {
+		t.Errorf("PUT debug: got %d %+v, want 200 and debug", code, p)
+	}
+	if code, p := serve("GET", ""); code != http.StatusOK || p.Level != "debug" {
+		t.Errorf("GET: got %d %+v, want 200 and debug", code, p)
+	}
+	if !globalLogger.CoreLogger().Core().Enabled(DebugLevel) {
+		t.Error("got debug disabled on the global logger")
+	}
+}
@@ -0,0 +1,88 @@
+package easylog
+
+import (
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// earlyLogsSize is the number of entries of the default global logger kept
+// for replay, the oldest ones being overwritten.
+const earlyLogsSize = 256
+
+var (
+	// earlyLogs buffers the entries of the default global logger until the
+	// first InitGlobalLogger replays them to its outputs. The default logger
+	// already wrote them to the console, so a configured console shows them
+	// twice.
+	earlyLogs *earlyBuffer
+
+	// teeEarlyLogs is the buffer the next logger built tees its entries to.
+	teeEarlyLogs *earlyBuffer
+)
+
+// earlyLogsOption makes the logger built with it buffer its entries to b.
+type earlyLogsOption struct {
+	b *earlyBuffer
+}
+
+func (o earlyLogsOption) Apply() {
+	teeEarlyLogs = o.b
+}
+
+// earlyBuffer is a ring buffer of entries, closed once replayed.
+type earlyBuffer struct {
+	mu      sync.Mutex
+	entries []LoggedEntry
+	next    int
+	closed  bool
+}
+
+func newEarlyBuffer(size int) *earlyBuffer {
+	return &earlyBuffer{entries: make([]LoggedEntry, 0, size)}
+}
+
+func (b *earlyBuffer) add(entry LoggedEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.closed:
+	case len(b.entries) < cap(b.entries):
+		b.entries = append(b.entries, entry)
+	default:
+		b.entries[b.next] = entry
+		b.next = (b.next + 1) % len(b.entries)
+	}
+}
+
+// close stops buffering and returns the buffered entries, oldest first.
+func (b *earlyBuffer) close() []LoggedEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	entries := append(b.entries[b.next:len(b.entries):len(b.entries)], b.entries[:b.next]...)
+	b.entries = nil
+	return entries
+}
+
+// replayEarlyLogs writes the entries the default global logger buffered
+// to core, once: the default global logger stops buffering.
+func replayEarlyLogs(core zapcore.Core) {
+	b := earlyLogs
+	if b == nil {
+		return
+	}
+	earlyLogs = nil
+	for _, entry := range b.close() {
+		if ce := core.Check(entry.Entry, nil); ce != nil {
+			ce.Write(entry.Context...)
+		}
+	}
+}
+
+// newEarlyCore returns a core buffering entries to b. It is the entryCore
+// shared with channel sinks and Capture, which keeps the context added by
+// With like a dedicated core would.
+func newEarlyCore(b *earlyBuffer) zapcore.Core {
+	return newEntryCore(b.add)
+}
@@ -0,0 +1,40 @@
+package easylog
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/logerror/easylog/pkg/option"
+	"go.uber.org/zap"
+)
+
+func TestReplayEarlyLogs(t *testing.T) {
+	setup(t)
+	prev := earlyLogs
+	t.Cleanup(func() { earlyLogs = prev })
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	// the state before InitGlobalLogger: the default global logger buffers
+	earlyLogs = nil
+	b := newEarlyBuffer(earlyLogsSize)
+	InitGlobalLogger(earlyLogsOption{b: b}, option.WithConsole(false))
+	earlyLogs = b
+	Info("early", zap.String("k", "v"))
+
+	InitGlobalLogger(option.WithLogFilePath(path))
+	Info("late")
+	Sync()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := decodeLines(t, string(data))
+	if len(entries) != 2 {
+		t.Fatalf("got %d lines, want 2", len(entries))
+	}
+	if entries[0]["msg"] != "early" || entries[0]["k"] != "v" || entries[1]["msg"] != "late" {
+		t.Errorf("got %v, want the early entry replayed before the late one", entries)
+	}
+}
@@ -0,0 +1,19 @@
+// Package easylogtest provides loggers for tests, kept apart from easylog so
+// that programs using easylog do not link the testing package.
+package easylogtest
+
+import (
+	"testing"
+
+	"github.com/logerror/easylog"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest"
+)
+
+// NewLogger returns a Logger writing every entry at any level through
+// tb.Log, so that the output is attributed to the test running it and only
+// shown when the test fails or runs verbosely. Entries logged after the test
+// completed fail it, as with zaptest.
+func NewLogger(tb testing.TB) easylog.Logger {
+	return easylog.NewLogger(zaptest.NewLogger(tb, zaptest.Level(zapcore.DebugLevel)))
+}
@@ -0,0 +1,39 @@
+package easylogtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recordingTB records the lines logged through it.
+type recordingTB struct {
+	testing.TB
+	mu    sync.Mutex
+	lines []string
+}
+
+func (r *recordingTB) Logf(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lines = append(r.lines, fmt.Sprintf(format, args...))
+}
+
+func TestNewLogger(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	l := NewLogger(tb)
+
+	l.Debug("direct")
+	l.WithContext(context.Background()).Info("with context")
+
+	if len(tb.lines) != 2 {
+		t.Fatalf("got %d lines in the test log, want 2: %q", len(tb.lines), tb.lines)
+	}
+	for i, msg := range []string{"direct", "with context"} {
+		if !strings.Contains(tb.lines[i], msg) || !strings.Contains(tb.lines[i], "easylogtest_test.go:") {
+			t.Errorf("got %q, want %s logged from easylogtest_test.go", tb.lines[i], msg)
+		}
+	}
+}
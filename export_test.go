@@ -0,0 +1,5 @@
+package easylog
+
+// LogFromExternalPackage logs through l from the external test package,
+// which sets it.
+var LogFromExternalPackage func(l Logger)
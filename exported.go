@@ -2,11 +2,13 @@ package easylog
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/logerror/easylog/pkg/izap"
 	"github.com/logerror/easylog/pkg/option"
-	otelzap "github.com/logerror/easylog/pkg/otel"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 func DefaultLogger() Logger {
@@ -25,6 +27,35 @@ func DefaultOtelSugaredLogger() izap.SugaredLogger {
 	return globalOtelSugaredLogger
 }
 
+// Level is the severity of an entry.
+type Level = option.Level
+
+// LoggedEntry is an entry written by a logger along with its fields, as
+// received by channel sinks and returned by Capture.
+type LoggedEntry = option.LoggedEntry
+
+// The levels of entries, in increasing severity.
+const (
+	DebugLevel Level = zapcore.DebugLevel
+	InfoLevel  Level = zapcore.InfoLevel
+	WarnLevel  Level = zapcore.WarnLevel
+	ErrorLevel Level = zapcore.ErrorLevel
+	PanicLevel Level = zapcore.PanicLevel
+	FatalLevel Level = zapcore.FatalLevel
+)
+
+// SetLevelByName sets the level of the global logger by its lowercase name,
+// such as "debug". Unlike ParseLevel, it reports unknown names instead of
+// falling back to info.
+func SetLevelByName(name string) error {
+	lvl, ok := option.LevelMapping[name]
+	if !ok {
+		return fmt.Errorf("easylog: unknown level %q", name)
+	}
+	SetLevel(lvl)
+	return nil
+}
+
 func SetLevel(lvl option.Level) {
 	globalLoggerLevel.SetLevel(lvl)
 }
@@ -39,12 +70,15 @@ func Named(s string) Logger {
 
 func (l *logger) Named(s string) Logger {
 	lg := l.logger.Named(s)
+	otelLogger := namedOtelLogger(l.otelLogger, lg, s)
 	return &logger{
 		level:             l.level,
+		atomicLevel:       l.atomicLevel,
+		config:            l.config,
 		logger:            lg,
 		sugaredLogger:     lg.Sugar(),
-		otelLogger:        otelzap.NewLogger(lg),
-		otelSugaredLogger: otelzap.NewSugaredLogger(lg.Sugar()),
+		otelLogger:        otelLogger,
+		otelSugaredLogger: otelLogger.Sugar(),
 	}
 }
 
@@ -54,27 +88,123 @@ func With(fields ...Field) Logger {
 
 func (l *logger) With(fields ...Field) Logger {
 	lg := l.logger.With(fields...)
+	// derive from the existing otel logger so its configuration is preserved
+	otelLogger := l.otelLogger.With(fields...)
 	return &logger{
 		level:             l.level,
+		atomicLevel:       l.atomicLevel,
+		config:            l.config,
 		logger:            lg,
 		sugaredLogger:     lg.Sugar(),
-		otelLogger:        otelzap.NewLogger(lg),
-		otelSugaredLogger: otelzap.NewSugaredLogger(lg.Sugar()),
+		otelLogger:        otelLogger,
+		otelSugaredLogger: otelLogger.Sugar(),
 	}
 }
 
 func N(ctx context.Context, name string) izap.StdLogger {
-	l := globalRawLogger.logger.Named(name)
-	return otelzap.NewLogger(l).WithContext(ctx)
+	return namedOtelLogger(globalRawLogger.otelLogger, globalRawLogger.logger.Named(name), name).WithContext(ctx)
+}
+
+// namedOtelLogger returns otelLogger named name, derived from it so that its
+// configuration is preserved if it supports naming, else built anew from lg,
+// the named logger it wraps.
+func namedOtelLogger(otelLogger izap.Logger, lg *zap.Logger, name string) izap.Logger {
+	if named, ok := otelLogger.(izap.NamedLogger); ok {
+		return named.Named(name)
+	}
+	otelLogger, _ = otelLoggers(lg)
+	return otelLogger
 }
 
+type preparedContextKey struct{}
+
+// prepared holds the loggers bound to a context by Prepare.
+type prepared struct {
+	// ctx is the context returned by Prepare: contexts derived from it may
+	// carry another span or other fields, so they do not reuse the loggers.
+	ctx           context.Context
+	logger        izap.StdLogger
+	sugaredLogger izap.StdSugaredLogger
+}
+
+// Prepare binds the global loggers to ctx once and stores them in the
+// returned context, so that repeated G and GS calls with it reuse the
+// computed trace fields. Contexts derived from the returned one get loggers
+// of their own. Loggers installed after Prepare are not picked up.
+func Prepare(ctx context.Context) context.Context {
+	p := &prepared{
+		logger:        G(ctx),
+		sugaredLogger: GS(ctx),
+	}
+	p.ctx = context.WithValue(ctx, preparedContextKey{}, p)
+	return p.ctx
+}
+
+// G returns the global logger bound to ctx, including the fields of the
+// correlation id, the operation, the hop count, the linked trace, the
+// transaction flag and the registered context extractors. It discards every
+// entry if the trace of ctx is muted, see MuteTrace, and logs at the level
+// of the trace if it is verbose, see TraceVerbose.
 func G(ctx context.Context) izap.StdLogger {
-	return WithContext(ctx)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if isMuted(ctx) {
+		return mutedLogger
+	}
+	if level, ok := verboseLevel(ctx); ok {
+		return globalOtelLogger.With(append(contextFields(ctx), levelMarker(level))...).WithContext(ctx)
+	}
+	if p, ok := ctx.Value(preparedContextKey{}).(*prepared); ok && p.ctx == ctx {
+		return p.logger
+	}
+	return globalOtelLogger.With(contextFields(ctx)...).WithContext(ctx)
 }
 
+// GS returns the global sugared logger bound to ctx, including the fields
+// of the correlation id, the operation, the hop count, the linked trace, the
+// transaction flag and the registered context extractors. It discards every
+// entry if the trace of ctx is muted, see MuteTrace, and logs at the level
+// of the trace if it is verbose, see TraceVerbose.
 func GS(ctx context.Context) izap.StdSugaredLogger {
-	return globalOtelSugaredLogger.WithContext(ctx)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if isMuted(ctx) {
+		return mutedSugaredLogger
+	}
+	if level, ok := verboseLevel(ctx); ok {
+		return globalOtelLogger.With(append(contextFields(ctx), levelMarker(level))...).Sugar().WithContext(ctx)
+	}
+	if p, ok := ctx.Value(preparedContextKey{}).(*prepared); ok && p.ctx == ctx {
+		return p.sugaredLogger
+	}
+	return globalOtelLogger.With(contextFields(ctx)...).Sugar().WithContext(ctx)
+}
+
+// Result logs the outcome of an operation through G(ctx): at error with
+// the error field if err is not nil, at info otherwise.
+func Result(ctx context.Context, msg string, err error, fields ...Field) {
+	if err != nil {
+		// copy, the caller's slice may have room for the error field
+		withErr := make([]Field, len(fields), len(fields)+1)
+		copy(withErr, fields)
+		G(ctx).Error(msg, append(withErr, zap.Error(err))...)
+		return
+	}
+	G(ctx).Info(msg, fields...)
+}
+
+// Success logs through G(ctx) at info whether the operation op succeeded, as
+// the op and success fields, and passes the outcome to the counter set with
+// option.WithSuccessCounter, if any.
+func Success(ctx context.Context, op string, ok bool) {
+	G(ctx).Info(op, zap.String("op", op), zap.Bool("success", ok))
+	if counter := option.SuccessCounter; counter != nil {
+		counter(op, ok)
+	}
 }
+
 func WithContext(ctx context.Context) izap.StdLogger {
 	return globalOtelLogger.WithContext(ctx)
 }
@@ -82,45 +212,93 @@ func (l *logger) WithContext(ctx context.Context) izap.StdLogger {
 	return l.otelLogger.WithContext(ctx)
 }
 
+func (l *logger) SugarCtx(ctx context.Context) izap.StdSugaredLogger {
+	return l.otelSugaredLogger.WithContext(ctx)
+}
+
 func Debug(msg string, fields ...Field) {
-	globalLogger.Debug(msg, fields...)
+	globalFuncLogger.Debug(msg, fields...)
 }
 func (l *logger) Debug(msg string, fields ...Field) {
 	l.logger.Debug(msg, fields...)
 }
 
 func Info(msg string, fields ...Field) {
-	globalLogger.Info(msg, fields...)
+	globalFuncLogger.Info(msg, fields...)
 }
 func (l *logger) Info(msg string, fields ...Field) {
 	l.logger.Info(msg, fields...)
 }
 
 func Warn(msg string, fields ...Field) {
-	globalLogger.Warn(msg, fields...)
+	globalFuncLogger.Warn(msg, fields...)
 }
 func (l *logger) Warn(msg string, fields ...Field) {
 	l.logger.Warn(msg, fields...)
 }
 
 func Error(msg string, fields ...Field) {
-	globalLogger.Error(msg, fields...)
+	globalFuncLogger.Error(msg, fields...)
 }
 func (l *logger) Error(msg string, fields ...Field) {
 	l.logger.Error(msg, fields...)
 }
 
+func Check(level option.Level, msg string) *zapcore.CheckedEntry {
+	return globalFuncLogger.Check(level, msg)
+}
+func (l *logger) Check(level option.Level, msg string) *zapcore.CheckedEntry {
+	return l.logger.Check(level, msg)
+}
+
+func (l *logger) WithLevel(level option.Level) Logger {
+	return l.With(levelMarker(level))
+}
+
+func (l *logger) WithSampler(initial, thereafter int) Logger {
+	var levelOf func() zapcore.Level
+	if l.atomicLevel != (zap.AtomicLevel{}) {
+		levelOf = l.atomicLevel.Level
+	}
+	// share one sampler between the plain and the otel logger
+	sampler := newSamplerCore(l.logger.Core(), time.Second, initial, thereafter, levelOf)
+	opt := zap.WrapCore(func(zapcore.Core) zapcore.Core {
+		return sampler
+	})
+	lg := l.logger.WithOptions(opt)
+	otelLogger := l.otelLogger.WithOptions(opt)
+	return &logger{
+		level:             l.level,
+		atomicLevel:       l.atomicLevel,
+		config:            l.config,
+		logger:            lg,
+		sugaredLogger:     lg.Sugar(),
+		otelLogger:        otelLogger,
+		otelSugaredLogger: otelLogger.Sugar(),
+	}
+}
+
 func (l *logger) Clone() Logger {
 	copyLogger := *l.logger
 	copySugaredLogger := *l.sugaredLogger
+	// derive from the existing otel logger so its configuration is preserved
+	otelLogger := l.otelLogger.WithOptions()
 	return &logger{
-		level:         l.level,
-		logger:        &copyLogger,
-		sugaredLogger: &copySugaredLogger,
+		level:             l.level,
+		atomicLevel:       l.atomicLevel,
+		config:            l.config,
+		logger:            &copyLogger,
+		sugaredLogger:     &copySugaredLogger,
+		otelLogger:        otelLogger,
+		otelSugaredLogger: otelLogger.Sugar(),
 	}
 }
 
 func (l *logger) Level() string {
+	// follow SetLevel on loggers sharing the dynamic level
+	if l.atomicLevel != (zap.AtomicLevel{}) {
+		return l.atomicLevel.Level().String()
+	}
 	return l.level
 }
 
@@ -128,12 +306,13 @@ func IsDebug() bool {
 	return globalLogger.IsDebug()
 }
 func (l *logger) IsDebug() bool {
-	return l.level == option.DebugLevel.String()
+	return l.Level() == option.DebugLevel.String()
 }
 
 func ReplaceLogger(l Logger) {
 	globalLogger = l
 	globalSugaredLogger = l.SugaredLogger()
+	globalFuncLogger, globalFuncSugaredLogger = funcLoggers(l)
 	zap.ReplaceGlobals(globalLogger.CoreLogger())
 }
 
@@ -191,56 +370,56 @@ func (s *sugaredLogger) Error(args ...interface{}) {
 }
 
 func Panic(args ...interface{}) {
-	globalSugaredLogger.Panic(args...)
+	globalFuncSugaredLogger.Panic(args...)
 }
 func (s *sugaredLogger) Panic(args ...interface{}) {
 	s.sugaredLogger.Panic(args...)
 }
 
 func Fatal(args ...interface{}) {
-	globalSugaredLogger.Fatal(args...)
+	globalFuncSugaredLogger.Fatal(args...)
 }
 func (s *sugaredLogger) Fatal(args ...interface{}) {
 	s.sugaredLogger.Fatal(args...)
 }
 
 func Debugf(format string, args ...interface{}) {
-	globalSugaredLogger.Debugf(format, args...)
+	globalFuncSugaredLogger.Debugf(format, args...)
 }
 func (s *sugaredLogger) Debugf(format string, args ...interface{}) {
 	s.sugaredLogger.Debugf(format, args...)
 }
 
 func Infof(format string, args ...interface{}) {
-	globalSugaredLogger.Infof(format, args...)
+	globalFuncSugaredLogger.Infof(format, args...)
 }
 func (s *sugaredLogger) Infof(format string, args ...interface{}) {
 	s.sugaredLogger.Infof(format, args...)
 }
 
 func Warnf(format string, args ...interface{}) {
-	globalSugaredLogger.Warnf(format, args...)
+	globalFuncSugaredLogger.Warnf(format, args...)
 }
 func (s *sugaredLogger) Warnf(format string, args ...interface{}) {
 	s.sugaredLogger.Warnf(format, args...)
 }
 
 func Errorf(format string, args ...interface{}) {
-	globalSugaredLogger.Errorf(format, args...)
+	globalFuncSugaredLogger.Errorf(format, args...)
 }
 func (s *sugaredLogger) Errorf(format string, args ...interface{}) {
 	s.sugaredLogger.Errorf(format, args...)
 }
 
 func Panicf(format string, args ...interface{}) {
-	globalSugaredLogger.Panicf(format, args...)
+	globalFuncSugaredLogger.Panicf(format, args...)
 }
 func (s *sugaredLogger) Panicf(format string, args ...interface{}) {
 	s.sugaredLogger.Panicf(format, args...)
 }
 
 func Fatalf(format string, args ...interface{}) {
-	globalSugaredLogger.Fatalf(format, args...)
+	globalFuncSugaredLogger.Fatalf(format, args...)
 }
 func (s *sugaredLogger) Fatalf(format string, args ...interface{}) {
 	s.sugaredLogger.Fatalf(format, args...)
@@ -0,0 +1,421 @@
+package easylog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/logerror/easylog/pkg/izap"
+	"github.com/logerror/easylog/pkg/option"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+func TestWithContextCallerAfterWithAndNamed(t *testing.T) {
+	l, buf := newBufferLogger(t)
+	tracer, _ := newTracer(t)
+	ctx, span := tracer.Start(context.Background(), "op")
+	defer span.End()
+
+	for _, lg := range []izap.StdLogger{
+		l.WithContext(ctx),
+		l.With(zap.String("k", "v")).WithContext(ctx),
+		l.Named("sub").WithContext(ctx),
+	} {
+		lg.Info("hello")
+	}
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 3 {
+		t.Fatalf("got %d lines, want 3", len(entries))
+	}
+	want := entries[0]["caller"].(string)
+	if !strings.Contains(want, "exported_test.go:") {
+		t.Fatalf("got caller %s, want exported_test.go", want)
+	}
+	for _, entry := range entries[1:] {
+		if entry["caller"] != want {
+			t.Errorf("got caller %v, want %s", entry["caller"], want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithLogLevel("info"))
+
+	if ce := l.Check(DebugLevel, "disabled"); ce != nil {
+		t.Error("got a CheckedEntry at a disabled level")
+	}
+	ce := l.Check(InfoLevel, "enabled")
+	if ce == nil {
+		t.Fatal("got no CheckedEntry at an enabled level")
+	}
+	ce.Write(zap.Int("n", 1))
+
+	if entry := decodeLine(t, buf.String()); entry["msg"] != "enabled" || entry["n"] != 1.0 {
+		t.Errorf("got %v, want msg enabled and n=1", entry)
+	}
+}
+
+func TestGNilContext(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+
+	G(nil).Info("logger")
+	GS(nil).Info("sugared")
+
+	if n := len(decodeLines(t, buf.String())); n != 2 {
+		t.Errorf("got %d lines, want 2", n)
+	}
+}
+
+func TestWithLevel(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithLogLevel("info"))
+
+	l.Debug("dropped")
+	l.WithLevel(DebugLevel).Debug("kept")
+
+	if entry := decodeLine(t, buf.String()); entry["msg"] != "kept" {
+		t.Errorf("got msg %v, want kept", entry["msg"])
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	initGlobalBufferLogger(t)
+	tracer, _ := newTracer(t)
+	ctx, span := tracer.Start(context.Background(), "op")
+	defer span.End()
+
+	prepared := Prepare(ctx)
+	if G(prepared) != G(prepared) {
+		t.Error("got distinct loggers for a prepared context")
+	}
+	plain := testing.AllocsPerRun(100, func() { G(ctx) })
+	if allocs := testing.AllocsPerRun(100, func() { G(prepared) }); allocs >= plain {
+		t.Errorf("got %v allocs with Prepare, want fewer than %v", allocs, plain)
+	}
+}
+
+func TestPrepareDerivedContext(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+	tracer, recorder := newTracer(t)
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	prepared := Prepare(ctx)
+
+	child, span := tracer.Start(prepared, "child")
+	G(WithOperation(child, "charge")).Error("failed")
+	GS(IncrementHop(prepared)).Info("hopped")
+	span.End()
+	parent.End()
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 2 {
+		t.Fatalf("got %d lines, want 2", len(entries))
+	}
+	if entries[0]["operation"] != "charge" {
+		t.Errorf("got operation %v, want charge", entries[0]["operation"])
+	}
+	if entries[1]["hop"] != 1.0 {
+		t.Errorf("got hop %v, want 1", entries[1]["hop"])
+	}
+	for _, s := range recorder.Ended() {
+		events, wantEvents := len(s.Events()), 0
+		wantStatus := codes.Unset
+		if s.Name() == "child" {
+			wantEvents, wantStatus = 1, codes.Error
+		}
+		if events != wantEvents || s.Status().Code != wantStatus {
+			t.Errorf("%s: got %d events and status %v, want %d and %v", s.Name(), events, s.Status().Code, wantEvents, wantStatus)
+		}
+	}
+}
+
+func benchmarkG(b *testing.B, prepare bool) {
+	setup(b)
+	InitGlobalLogger()
+	SetOutput(io.Discard)
+	tracer, _ := newTracer(b)
+	ctx, span := tracer.Start(context.Background(), "op")
+	defer span.End()
+	if prepare {
+		ctx = Prepare(ctx)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		G(ctx)
+	}
+}
+
+func BenchmarkG(b *testing.B) {
+	benchmarkG(b, false)
+}
+
+func BenchmarkGPrepared(b *testing.B) {
+	benchmarkG(b, true)
+}
+
+func TestWithSampler(t *testing.T) {
+	l, buf := newBufferLogger(t)
+	sampled := l.With(zap.String("component", "noisy")).WithSampler(2, 5)
+
+	for i := 0; i < 12; i++ {
+		sampled.Info("sampled")
+		l.Info("parent")
+	}
+
+	counts := map[interface{}]int{}
+	for _, entry := range decodeLines(t, buf.String()) {
+		counts[entry["msg"]]++
+	}
+	// the first 2, then the 7th and 12th
+	if counts["sampled"] != 4 || counts["parent"] != 12 {
+		t.Errorf("got %d sampled and %d parent lines, want 4 and 12", counts["sampled"], counts["parent"])
+	}
+}
+
+func TestWithSamplerLevelChange(t *testing.T) {
+	buf := initGlobalBufferLogger(t, option.WithLogLevel("info"))
+	sampled := globalRawLogger.WithSampler(2, 0)
+
+	for i := 0; i < 5; i++ {
+		sampled.Info("before")
+	}
+	SetDebug()
+	for i := 0; i < 5; i++ {
+		sampled.Info("after")
+		sampled.Debug("debug")
+	}
+
+	counts := map[interface{}]int{}
+	for _, entry := range decodeLines(t, buf.String()) {
+		counts[entry["msg"]]++
+	}
+	if counts["before"] != 2 || counts["after"] != 5 || counts["debug"] != 5 {
+		t.Errorf("got %d before, %d after and %d debug lines, want 2, 5 and 5", counts["before"], counts["after"], counts["debug"])
+	}
+}
+
+func TestRemoteSpanContext(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+	remote := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{0xab},
+		SpanID:  trace.SpanID{0xcd},
+		Remote:  true,
+	})
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), remote)
+
+	G(ctx).Info("G")
+	GS(ctx).Info("GS")
+	N(ctx, "sub").Info("N")
+	WithContext(ctx).Info("WithContext")
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 4 {
+		t.Fatalf("got %d lines, want 4", len(entries))
+	}
+	for _, entry := range entries {
+		if entry["trace_id"] != remote.TraceID().String() {
+			t.Errorf("%v: got trace_id %v, want %s", entry["msg"], entry["trace_id"], remote.TraceID())
+		}
+	}
+}
+
+func TestWithDeadlineAwareSampling(t *testing.T) {
+	buf := initGlobalBufferLogger(t, option.WithDeadlineAwareSampling(3, time.Minute))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	for i := 0; i < 6; i++ {
+		G(ctx).Info("far")
+	}
+
+	if n := len(decodeLines(t, buf.String())); n != 2 {
+		t.Errorf("got %d lines, want 2", n)
+	}
+}
+
+func TestGSKeyWithoutValue(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+
+	GS(context.Background()).Infow("odd", "lonelykey")
+
+	entry := decodeLine(t, buf.String())
+	if entry["log_arg_error"] != "key without a value: lonelykey" {
+		t.Errorf("got log_arg_error %v, want the lonely key", entry["log_arg_error"])
+	}
+	if caller, _ := entry["caller"].(string); !strings.Contains(caller, "exported_test.go:") {
+		t.Errorf("got caller %v, want exported_test.go", entry["caller"])
+	}
+}
+
+func TestDerivedLoggersFollowLevel(t *testing.T) {
+	buf := initGlobalBufferLogger(t, option.WithLogLevel("info"))
+	named := globalRawLogger.Named("sub")
+	with := globalRawLogger.With(zap.String("k", "v"))
+
+	SetDebug()
+	named.Debug("named")
+	with.Debug("with")
+	named.WithContext(context.Background()).Debug("named otel")
+
+	if n := len(decodeLines(t, buf.String())); n != 3 {
+		t.Errorf("got %d lines, want 3", n)
+	}
+}
+
+func TestResult(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+	fields := make([]Field, 1, 2)
+	fields[0] = zap.String("k", "v")
+
+	Result(context.Background(), "ok", nil, fields...)
+	Result(context.Background(), "failed", errors.New("boom"), fields...)
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 2 {
+		t.Fatalf("got %d lines, want 2", len(entries))
+	}
+	if entries[0]["level"] != "info" || entries[0]["error"] != nil {
+		t.Errorf("got %v, want info without error", entries[0])
+	}
+	if entries[1]["level"] != "error" || entries[1]["error"] != "boom" || entries[1]["k"] != "v" {
+		t.Errorf("got %v, want error with error=boom and k=v", entries[1])
+	}
+	if fields[:2][1] != (Field{}) {
+		t.Errorf("got %v appended to the caller's fields", fields[:2][1])
+	}
+}
+
+func TestPackageFunctionsCaller(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+
+	for _, log := range []func(string, ...Field){Info, globalLogger.Info, Error, globalLogger.Error} {
+		log("same line")
+	}
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 4 {
+		t.Fatalf("got %d lines, want 4", len(entries))
+	}
+	want := entries[0]["caller"].(string)
+	if !strings.Contains(want, "exported_test.go:") {
+		t.Fatalf("got caller %s, want exported_test.go", want)
+	}
+	for _, entry := range entries[1:] {
+		if entry["caller"] != want {
+			t.Errorf("got caller %v, want %s", entry["caller"], want)
+		}
+	}
+}
+
+func TestSetLevelByName(t *testing.T) {
+	buf := initGlobalBufferLogger(t, option.WithLogLevel("info"))
+
+	SetLevel(WarnLevel)
+	Info("dropped")
+	if err := SetLevelByName("debug"); err != nil {
+		t.Fatal(err)
+	}
+	Debug("kept")
+	if err := SetLevelByName("verbose"); err == nil {
+		t.Error("got no error for an unknown level")
+	}
+
+	if entry := decodeLine(t, buf.String()); entry["msg"] != "kept" {
+		t.Errorf("got msg %v, want kept", entry["msg"])
+	}
+	if lvl := globalLoggerLevel.Level(); lvl != DebugLevel {
+		t.Errorf("got level %v after an unknown name, want debug", lvl)
+	}
+}
+
+func TestSuccess(t *testing.T) {
+	counts := map[bool]int{}
+	buf := initGlobalBufferLogger(t, option.WithSuccessCounter(func(op string, ok bool) {
+		if op == "charge" {
+			counts[ok]++
+		}
+	}))
+
+	Success(context.Background(), "charge", true)
+	Success(context.Background(), "charge", false)
+	Success(context.Background(), "charge", true)
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 3 {
+		t.Fatalf("got %d lines, want 3", len(entries))
+	}
+	for i, want := range []bool{true, false, true} {
+		if entries[i]["level"] != "info" || entries[i]["op"] != "charge" || entries[i]["success"] != want {
+			t.Errorf("got %v, want info with op=charge and success=%v", entries[i], want)
+		}
+	}
+	if counts[true] != 2 || counts[false] != 1 {
+		t.Errorf("got %d successes and %d failures counted, want 2 and 1", counts[true], counts[false])
+	}
+}
+
+func TestDefaultOtelLoggersCaller(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+	tracer, _ := newTracer(t)
+	ctx, span := tracer.Start(context.Background(), "op")
+	defer span.End()
+
+	DefaultOtelSugaredLogger().Info("otel sugared")
+	DefaultOtelLogger().Info("otel")
+	DefaultOtelSugaredLogger().WithContext(ctx).Info("otel sugared with context")
+	DefaultOtelLogger().WithContext(ctx).Info("otel with context")
+	GetSugaredLogger().Info("sugared")
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 5 {
+		t.Fatalf("got %d lines, want 5", len(entries))
+	}
+	for _, entry := range entries {
+		if caller, _ := entry["caller"].(string); !strings.Contains(caller, "exported_test.go:") {
+			t.Errorf("%v: got caller %v, want exported_test.go", entry["msg"], entry["caller"])
+		}
+	}
+}
+
+func TestSugarCtx(t *testing.T) {
+	l, buf := newBufferLogger(t)
+	tracer, _ := newTracer(t)
+	ctx, span := tracer.Start(context.Background(), "op")
+	defer span.End()
+
+	l.SugarCtx(ctx).Infow("traced", "k", "v")
+
+	entry := decodeLine(t, buf.String())
+	if entry["trace_id"] != span.SpanContext().TraceID().String() || entry["k"] != "v" {
+		t.Errorf("got %v, want trace_id %s and k=v", entry, span.SpanContext().TraceID())
+	}
+}
+
+func TestClone(t *testing.T) {
+	buf := initGlobalBufferLogger(t, option.WithLogLevel("info"))
+	tracer, recorder := newTracer(t)
+	ctx, span := tracer.Start(context.Background(), "op")
+
+	clone := globalLogger.Clone()
+	clone.WithContext(ctx).Error("cloned")
+	SetDebug()
+	clone.Debug("follows the level")
+	span.End()
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 2 {
+		t.Fatalf("got %d lines, want 2", len(entries))
+	}
+	if entries[0]["trace_id"] != span.SpanContext().TraceID().String() {
+		t.Errorf("got trace_id %v, want %s", entries[0]["trace_id"], span.SpanContext().TraceID())
+	}
+	if events := recorder.Ended()[0].Events(); len(events) != 1 {
+		t.Errorf("got %d span events, want 1", len(events))
+	}
+}
@@ -0,0 +1,9 @@
+package easylog_test
+
+import "github.com/logerror/easylog"
+
+func init() {
+	easylog.LogFromExternalPackage = func(l easylog.Logger) {
+		l.Info("there")
+	}
+}
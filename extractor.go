@@ -0,0 +1,59 @@
+package easylog
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// ContextExtractor maps values carried by a context to log fields.
+type ContextExtractor interface {
+	Extract(ctx context.Context) []Field
+}
+
+// ContextExtractorFunc adapts a function to a ContextExtractor.
+type ContextExtractorFunc func(ctx context.Context) []Field
+
+func (f ContextExtractorFunc) Extract(ctx context.Context) []Field {
+	return f(ctx)
+}
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []ContextExtractor
+)
+
+// RegisterContextExtractor registers e to add its fields to the loggers
+// returned by G and GS.
+func RegisterContextExtractor(e ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors = append(extractors, e)
+}
+
+// contextFields returns the correlation id, operation, hop count, linked
+// trace id and transaction flag of ctx and the fields of all registered
+// extractors. The hop count and the transaction flag are always included,
+// 0 and false by default.
+func contextFields(ctx context.Context) []Field {
+	var fields []Field
+	if id, ok := CorrelationID(ctx); ok {
+		fields = append(fields, zap.String(correlationIdKey, id))
+	}
+	if name, ok := Operation(ctx); ok {
+		fields = append(fields, zap.String(operationKey, name))
+	}
+	fields = append(fields, zap.Int(hopKey, Hop(ctx)))
+	if id, ok := LinkedTraceID(ctx); ok {
+		fields = append(fields, zap.String(linkedTraceIdKey, id))
+	}
+	fields = append(fields, zap.Bool(inTxKey, InTx(ctx)))
+
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+	for _, e := range extractors {
+		fields = append(fields, e.Extract(ctx)...)
+	}
+	return fields
+}
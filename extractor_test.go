@@ -0,0 +1,35 @@
+package easylog
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+type userIdContextKey struct{}
+
+func TestRegisterContextExtractor(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+	RegisterContextExtractor(ContextExtractorFunc(func(ctx context.Context) []Field {
+		if id, ok := ctx.Value(userIdContextKey{}).(string); ok {
+			return []Field{zap.String("user_id", id)}
+		}
+		return nil
+	}))
+
+	ctx := context.WithValue(context.Background(), userIdContextKey{}, "u-42")
+	G(ctx).Info("logger")
+	GS(ctx).Info("sugared")
+	G(context.Background()).Info("anonymous")
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 3 {
+		t.Fatalf("got %d lines, want 3", len(entries))
+	}
+	for i, want := range []interface{}{"u-42", "u-42", nil} {
+		if got := entries[i]["user_id"]; got != want {
+			t.Errorf("%v: got user_id %v, want %v", entries[i]["msg"], got, want)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package easylog
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/logerror/easylog/pkg/option"
+	otelzap "github.com/logerror/easylog/pkg/otel"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// MapFields expands m into one string field per entry, keyed as prefix.key.
+// Keys are emitted in sorted order so the output is deterministic.
+func MapFields(prefix string, m map[string]string) []Field {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]Field, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, zap.String(prefix+"."+k, m[k]))
+	}
+	return fields
+}
+
+// byteSize is a byte count that encodes as a plain integer in JSON and
+// prints in IEC units (e.g. "1.5 MiB") as a string.
+type byteSize int64
+
+func (b byteSize) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(b), 10), nil
+}
+
+func (b byteSize) String() string {
+	const unit = 1024
+	n := int64(b)
+	abs := n
+	if abs < 0 {
+		abs = -abs
+	}
+	if abs < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := abs / unit; m >= unit && exp < 5; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// Bytes constructs a field carrying a byte count. JSON output keeps the raw
+// integer, human-oriented output renders it in IEC units such as "1.5 MiB".
+func Bytes(key string, n int64) Field {
+	return Field{Key: key, Type: zapcore.ReflectType, Interface: byteSize(n)}
+}
+
+// Objects constructs a field holding an array of objects, each encoded by
+// its own MarshalLogObject instead of by reflection.
+func Objects(key string, items []zapcore.ObjectMarshaler) Field {
+	return zap.Array(key, zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		for _, item := range items {
+			if err := enc.AppendObject(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// FieldsToMap encodes fields into a map keyed by field name, which is handy
+// for asserting on logged fields in tests.
+func FieldsToMap(fields ...Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+// diff lazily encodes the exported struct fields that differ between before
+// and after as {field: {old, new}}.
+type diff struct {
+	before, after interface{}
+}
+
+func (d diff) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	before, after := derefValue(d.before), derefValue(d.after)
+	if before.Kind() != reflect.Struct || !after.IsValid() || before.Type() != after.Type() {
+		// not comparable field by field, record both values as a whole
+		if !reflect.DeepEqual(d.before, d.after) {
+			return enc.AddObject("value", change{d.before, d.after})
+		}
+		return nil
+	}
+
+	t := before.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			continue
+		}
+		oldValue, newValue := before.Field(i).Interface(), after.Field(i).Interface()
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		if err := enc.AddObject(t.Field(i).Name, change{oldValue, newValue}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// change is an old and new value pair of a diff.
+type change struct {
+	oldValue, newValue interface{}
+}
+
+func (c change) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if err := enc.AddReflected("old", c.oldValue); err != nil {
+		return err
+	}
+	return enc.AddReflected("new", c.newValue)
+}
+
+// derefValue returns the value v points to, following pointers.
+func derefValue(v interface{}) reflect.Value {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+// Diff constructs a field holding the shallow difference between two values
+// of the same struct type: only the exported fields that changed are logged,
+// as {field: {old, new}}. Pointers to structs are followed.
+func Diff(key string, before, after interface{}) Field {
+	return zap.Object(key, diff{before: before, after: after})
+}
+
+// LevelField wraps field so that it is only written for entries at or above
+// minLevel, e.g. to attach a heavy request dump to error lines only.
+func LevelField(minLevel option.Level, field Field) Field {
+	return Field{Key: field.Key, Type: zapcore.SkipType, Interface: levelField{minLevel: minLevel, field: field}}
+}
+
+// KV converts alternating keys and values into typed fields. Instead of
+// silently dropping malformed input, a non-string key or a trailing key
+// without a value is reported in a kv_error field.
+func KV(keysAndValues ...interface{}) []Field {
+	fields := make([]Field, 0, len(keysAndValues)/2+1)
+	var errs []string
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 == len(keysAndValues) {
+			errs = append(errs, fmt.Sprintf("key without a value: %v", keysAndValues[i]))
+			break
+		}
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			errs = append(errs, fmt.Sprintf("non-string key: %v", keysAndValues[i]))
+			continue
+		}
+		fields = append(fields, zap.Any(key, keysAndValues[i+1]))
+	}
+	if len(errs) > 0 {
+		fields = append(fields, zap.Strings("kv_error", errs))
+	}
+	return fields
+}
+
+// NoSpanError returns a field marking an error entry as expected, so that
+// loggers bound to a context do not set the status of its span to error.
+func NoSpanError() Field {
+	return otelzap.NoSpanError()
+}
+
+// codedError encodes an error together with the code identifying its type.
+type codedError struct {
+	err error
+}
+
+func (e codedError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	zap.Error(e.err).AddTo(enc)
+	enc.AddString("error_code", errorCode(e.err))
+	return nil
+}
+
+// errorCodeVariable matches the parts of error messages that vary between
+// occurrences of the same error, such as ids and sizes.
+var errorCodeVariable = regexp.MustCompile(`0x[0-9a-fA-F]+|[0-9]+`)
+
+// errorCode hashes the dynamic type and the message of the innermost error
+// wrapped by err, the sentinel or cause, with numbers masked, so that it
+// changes neither with the wrapping context nor with ids in the message.
+func errorCode(err error) string {
+	for next := errors.Unwrap(err); next != nil; next = errors.Unwrap(err) {
+		err = next
+	}
+	h := fnv.New32a()
+	_, _ = fmt.Fprintf(h, "%T:%s", err, errorCodeVariable.ReplaceAllString(err.Error(), "N"))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// CodedError constructs a field adding both the error and an error_code
+// identifying its kind, derived from the type and message of the error it
+// wraps, so that alerting can group errors of the same kind whatever their
+// context or the ids in their message. A nil error is skipped.
+func CodedError(err error) Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	return zap.Inline(codedError{err: err})
+}
+
+// RuntimeStatsFields returns the number of goroutines, the allocated heap and
+// the duration of the last GC pause, for quick diagnostics without a metrics
+// pipeline. It stops the world briefly to read the memory statistics.
+func RuntimeStatsFields() []Field {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	var lastPause time.Duration
+	if stats.NumGC > 0 {
+		lastPause = time.Duration(stats.PauseNs[(stats.NumGC+255)%256])
+	}
+
+	return []Field{
+		zap.Int("goroutines", runtime.NumGoroutine()),
+		Bytes("heap_alloc", int64(stats.HeapAlloc)),
+		zap.Duration("gc_pause", lastPause),
+	}
+}
+
+// defaultSizeThresholds are the thresholds of SizeBucket if none are given.
+var defaultSizeThresholds = []int64{1 << 10, 1 << 20}
+
+// SizeBucket constructs a field labelling a size in bytes by the bucket it
+// falls in: below the first of the ascending thresholds, below the second
+// and so on. One or two thresholds yield the labels small, (medium,) large,
+// more thresholds number the buckets as bucket_0, bucket_1... Without
+// thresholds, the buckets are split at 1 KiB and 1 MiB.
+func SizeBucket(key string, bytes int64, thresholds ...int64) Field {
+	if len(thresholds) == 0 {
+		thresholds = defaultSizeThresholds
+	}
+
+	bucket := len(thresholds)
+	for i, t := range thresholds {
+		if bytes < t {
+			bucket = i
+			break
+		}
+	}
+
+	var label string
+	switch {
+	case len(thresholds) > 2:
+		label = "bucket_" + strconv.Itoa(bucket)
+	case bucket == 0:
+		label = "small"
+	case bucket == len(thresholds):
+		label = "large"
+	default:
+		label = "medium"
+	}
+	return zap.String(key, label)
+}
+
+// window is a time range encoding its duration along with its bounds.
+type window struct {
+	start, end time.Time
+}
+
+func (w window) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddTime("start", w.start)
+	enc.AddTime("end", w.end)
+	enc.AddDuration("duration", w.end.Sub(w.start))
+	return nil
+}
+
+// Window constructs a field holding the time range from start to end as
+// {start, end, duration}, encoded with the time and duration encoders of the
+// logger.
+func Window(key string, start, end time.Time) Field {
+	return zap.Object(key, window{start: start, end: end})
+}
+
+// Addr constructs a field with the canonical string of addr, e.g. host:port
+// with IPv6 hosts bracketed as in [::1]:8080. A nil addr is skipped.
+func Addr(key string, addr net.Addr) Field {
+	if addr == nil {
+		return zap.Skip()
+	}
+	return zap.Stringer(key, addr)
+}
+
+// IP constructs a field with the canonical string of ip: dotted decimal for
+// IPv4, including IPv4-mapped IPv6 addresses, and RFC 5952 for IPv6. A nil
+// ip is skipped.
+func IP(key string, ip net.IP) Field {
+	if ip == nil {
+		return zap.Skip()
+	}
+	return zap.Stringer(key, ip)
+}
+
+// Stringer constructs a field with the String of v, e.g. the name of an enum
+// value rather than the integer zap.Any would log.
+func Stringer(key string, v fmt.Stringer) Field {
+	return zap.Stringer(key, v)
+}
+
+// enum logs an enum value as its name and its underlying number.
+type enum struct {
+	v fmt.Stringer
+}
+
+func (e enum) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", e.v.String())
+	switch rv := reflect.ValueOf(e.v); rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		enc.AddInt64("value", rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		enc.AddUint64("value", rv.Uint())
+	}
+	return nil
+}
+
+// Enum constructs a field holding an object with both the name of the enum
+// value v, its String, and its number, e.g. {"name":"ready","value":2}. The
+// number is left out if the type of v is not an integer. A nil v is skipped.
+func Enum(key string, v fmt.Stringer) Field {
+	if v == nil {
+		return zap.Skip()
+	}
+	return zap.Object(key, enum{v: v})
+}
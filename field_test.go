@@ -0,0 +1,296 @@
+package easylog
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/logerror/easylog/pkg/option"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestMapFields(t *testing.T) {
+	fields := MapFields("meta", map[string]string{"region": "eu", "env": "prod"})
+
+	if len(fields) != 2 {
+		t.Fatalf("got %d fields, want 2", len(fields))
+	}
+	// sorted by key
+	if fields[0].Key != "meta.env" || fields[0].String != "prod" {
+		t.Errorf("got %s=%s, want meta.env=prod", fields[0].Key, fields[0].String)
+	}
+	if fields[1].Key != "meta.region" || fields[1].String != "eu" {
+		t.Errorf("got %s=%s, want meta.region=eu", fields[1].Key, fields[1].String)
+	}
+}
+
+func TestBytesJSON(t *testing.T) {
+	l, buf := newBufferLogger(t)
+
+	l.With(Bytes("limit", 2<<20)).Info("sized", Bytes("size", 1536<<10))
+
+	entry := decodeLine(t, buf.String())
+	if entry["size"] != float64(1536<<10) || entry["limit"] != float64(2<<20) {
+		t.Errorf("got size %v and limit %v, want raw integers", entry["size"], entry["limit"])
+	}
+}
+
+func TestBytesConsole(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithEncoding("console"))
+
+	l.With(Bytes("limit", 2<<20)).Info("sized", Bytes("size", 1536<<10))
+
+	out := buf.String()
+	if !strings.Contains(out, `"size": "1.5 MiB"`) || !strings.Contains(out, `"limit": "2.0 MiB"`) {
+		t.Errorf("got %q, want size 1.5 MiB and limit 2.0 MiB", out)
+	}
+}
+
+func TestFieldsToMap(t *testing.T) {
+	m := FieldsToMap(
+		zap.String("s", "v"),
+		zap.Int("n", 42),
+		zap.Object("o", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("inner", "x")
+			return nil
+		})),
+	)
+
+	if m["s"] != "v" {
+		t.Errorf("got s=%v, want v", m["s"])
+	}
+	if m["n"] != int64(42) {
+		t.Errorf("got n=%v (%T), want 42", m["n"], m["n"])
+	}
+	if o, ok := m["o"].(map[string]interface{}); !ok || o["inner"] != "x" {
+		t.Errorf("got o=%v, want {inner: x}", m["o"])
+	}
+}
+
+func TestDiff(t *testing.T) {
+	type user struct {
+		Name  string
+		Email string
+		Age   int
+	}
+	before := user{Name: "ada", Email: "ada@example.com", Age: 36}
+	after := before
+	after.Email = "ada@example.org"
+
+	m := FieldsToMap(Diff("changes", before, &after))
+
+	want := map[string]interface{}{
+		"Email": map[string]interface{}{"old": "ada@example.com", "new": "ada@example.org"},
+	}
+	if !reflect.DeepEqual(m["changes"], want) {
+		t.Errorf("got %v, want %v", m["changes"], want)
+	}
+}
+
+func TestKV(t *testing.T) {
+	fields := KV("a", 1, "b", "x")
+
+	if len(fields) != 2 {
+		t.Fatalf("got %d fields, want 2", len(fields))
+	}
+	if fields[0].Key != "a" || fields[0].Type != zapcore.Int64Type || fields[0].Integer != 1 {
+		t.Errorf("got %+v, want int a=1", fields[0])
+	}
+	if fields[1].Key != "b" || fields[1].Type != zapcore.StringType || fields[1].String != "x" {
+		t.Errorf("got %+v, want string b=x", fields[1])
+	}
+}
+
+func TestKVMalformed(t *testing.T) {
+	m := FieldsToMap(KV("a", 1, 2, "x", "c")...)
+
+	if m["a"] != int64(1) {
+		t.Errorf("got a=%v, want 1", m["a"])
+	}
+	want := []interface{}{"non-string key: 2", "key without a value: c"}
+	if !reflect.DeepEqual(m["kv_error"], want) {
+		t.Errorf("got kv_error %v, want %v", m["kv_error"], want)
+	}
+}
+
+type item struct {
+	id   int
+	name string
+}
+
+func (i item) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt("id", i.id)
+	enc.AddString("name", i.name)
+	return nil
+}
+
+func TestObjects(t *testing.T) {
+	l, buf := newBufferLogger(t)
+
+	l.Info("items", Objects("items", []zapcore.ObjectMarshaler{item{1, "a"}, item{2, "b"}}))
+
+	entry := decodeLine(t, buf.String())
+	want := []interface{}{
+		map[string]interface{}{"id": 1.0, "name": "a"},
+		map[string]interface{}{"id": 2.0, "name": "b"},
+	}
+	if !reflect.DeepEqual(entry["items"], want) {
+		t.Errorf("got items %v, want %v", entry["items"], want)
+	}
+}
+
+var errNotFound = errors.New("not found")
+
+type timeoutError struct{ op string }
+
+func (e *timeoutError) Error() string { return e.op + " timed out" }
+
+func TestCodedError(t *testing.T) {
+	code := func(err error) interface{} {
+		return FieldsToMap(CodedError(err))["error_code"]
+	}
+
+	same := [][2]error{
+		{errNotFound, fmt.Errorf("load user 42: %w", errNotFound)},
+		{errors.New("user 42 not found"), errors.New("user 43 not found")},
+		{&timeoutError{op: "read"}, fmt.Errorf("sync: %w", &timeoutError{op: "read"})},
+	}
+	for _, errs := range same {
+		if code(errs[0]) != code(errs[1]) {
+			t.Errorf("got codes %v and %v for %q and %q, want the same", code(errs[0]), code(errs[1]), errs[0], errs[1])
+		}
+	}
+
+	different := [][2]error{
+		{errNotFound, errors.New("permission denied")},
+		{errNotFound, &timeoutError{op: "not found"}},
+		{&timeoutError{op: "read"}, &timeoutError{op: "write"}},
+	}
+	for _, errs := range different {
+		if code(errs[0]) == code(errs[1]) {
+			t.Errorf("got code %v for both %q and %q, want different ones", code(errs[0]), errs[0], errs[1])
+		}
+	}
+
+	if m := FieldsToMap(CodedError(errNotFound)); m["error"] != "not found" {
+		t.Errorf("got error %v, want not found", m["error"])
+	}
+}
+
+func TestRuntimeStatsFields(t *testing.T) {
+	m := FieldsToMap(RuntimeStatsFields()...)
+
+	for _, key := range []string{"goroutines", "heap_alloc", "gc_pause"} {
+		if _, ok := m[key]; !ok {
+			t.Errorf("got no %s field", key)
+		}
+	}
+	if n, _ := m["goroutines"].(int64); n <= 0 {
+		t.Errorf("got goroutines=%v, want a positive count", m["goroutines"])
+	}
+}
+
+func TestRuntimeStatsFieldsConsole(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithEncoding("console"))
+
+	l.Info("stats", RuntimeStatsFields()...)
+
+	if !regexp.MustCompile(`"heap_alloc": "[0-9.]+ [KMG]?i?B"`).MatchString(buf.String()) {
+		t.Errorf("got %q, want a human-readable heap_alloc", buf.String())
+	}
+}
+
+func TestSizeBucket(t *testing.T) {
+	tests := []struct {
+		bytes      int64
+		thresholds []int64
+		want       string
+	}{
+		{500, []int64{1 << 10, 1 << 20}, "small"},
+		{2 << 10, []int64{1 << 10, 1 << 20}, "medium"},
+		{2 << 20, []int64{1 << 10, 1 << 20}, "large"},
+		{2 << 20, nil, "large"},
+		{5, []int64{10}, "small"},
+		{50, []int64{10, 100, 1000}, "bucket_1"},
+	}
+	for _, tt := range tests {
+		if f := SizeBucket("size", tt.bytes, tt.thresholds...); f.String != tt.want {
+			t.Errorf("SizeBucket(%d, %v): got %s, want %s", tt.bytes, tt.thresholds, f.String, tt.want)
+		}
+	}
+}
+
+func TestWindow(t *testing.T) {
+	l, buf := newBufferLogger(t)
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	l.Info("scheduled", Window("window", start, start.Add(90*time.Minute)))
+
+	window, ok := decodeLine(t, buf.String())["window"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("got no window object")
+	}
+	for _, key := range []string{"start", "end"} {
+		if _, ok := window[key].(string); !ok {
+			t.Errorf("got %s=%v, want an encoded time", key, window[key])
+		}
+	}
+	if window["duration"] != "1h30m0s" {
+		t.Errorf("got duration %v, want 1h30m0s", window["duration"])
+	}
+}
+
+func TestAddr(t *testing.T) {
+	m := FieldsToMap(
+		Addr("v4", &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 8080}),
+		Addr("v6", &net.TCPAddr{IP: net.ParseIP("::1"), Port: 443}),
+		Addr("none", nil),
+	)
+
+	if m["v4"] != "10.0.0.1:8080" || m["v6"] != "[::1]:443" {
+		t.Errorf("got v4=%v and v6=%v, want 10.0.0.1:8080 and [::1]:443", m["v4"], m["v6"])
+	}
+	if _, ok := m["none"]; ok {
+		t.Errorf("got none=%v, want it skipped", m["none"])
+	}
+}
+
+func TestIP(t *testing.T) {
+	m := FieldsToMap(
+		IP("v4", net.IPv4(192, 168, 1, 2)),
+		IP("v6", net.ParseIP("2001:0db8:0000:0000:0000:0000:0000:0001")),
+	)
+
+	if m["v4"] != "192.168.1.2" || m["v6"] != "2001:db8::1" {
+		t.Errorf("got v4=%v and v6=%v, want 192.168.1.2 and 2001:db8::1", m["v4"], m["v6"])
+	}
+}
+
+type state int
+
+func (s state) String() string {
+	return [...]string{"idle", "busy", "ready"}[s]
+}
+
+func TestStringer(t *testing.T) {
+	if m := FieldsToMap(Stringer("state", state(2))); m["state"] != "ready" {
+		t.Errorf("got state %v, want ready", m["state"])
+	}
+}
+
+func TestEnum(t *testing.T) {
+	l, buf := newBufferLogger(t)
+
+	l.Info("changed", Enum("state", state(2)))
+
+	want := map[string]interface{}{"name": "ready", "value": 2.0}
+	if got := decodeLine(t, buf.String())["state"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("got state %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,105 @@
+package easylog
+
+import (
+	"runtime"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	// instances are the cores of the loggers built by InitLogger and
+	// InitGlobalLogger, by registration id. The registry does not keep the
+	// loggers alive: a logger is unregistered once neither it nor any logger
+	// derived from it is reachable.
+	instancesMu    sync.Mutex
+	instances      = map[uint64]zapcore.Core{}
+	nextInstanceID uint64
+)
+
+// registration identifies a registered core. It is only referenced by the
+// loggers writing to the core, and unregisters it once collected.
+type registration struct {
+	id uint64
+	// _ keeps the registration out of the tiny allocator, whose objects
+	// may never be finalized
+	_ *byte
+}
+
+// registeredCore keeps the registration of its core alive.
+type registeredCore struct {
+	zapcore.Core
+	reg *registration
+}
+
+// registerCore adds core to the cores synced by SyncAll until the returned
+// core and the cores derived from it are collected.
+func registerCore(core zapcore.Core) zapcore.Core {
+	instancesMu.Lock()
+	nextInstanceID++
+	reg := &registration{id: nextInstanceID}
+	instances[reg.id] = core
+	instancesMu.Unlock()
+
+	runtime.SetFinalizer(reg, unregisterCore)
+	return &registeredCore{Core: core, reg: reg}
+}
+
+func unregisterCore(reg *registration) {
+	instancesMu.Lock()
+	defer instancesMu.Unlock()
+	delete(instances, reg.id)
+}
+
+func (c *registeredCore) With(fields []zapcore.Field) zapcore.Core {
+	return &registeredCore{
+		Core: c.Core.With(fields),
+		reg:  c.reg,
+	}
+}
+
+// SyncAll syncs every logger built by InitLogger and InitGlobalLogger that is
+// still in use, e.g. before the process exits.
+func SyncAll() {
+	instancesMu.Lock()
+	all := make([]zapcore.Core, 0, len(instances))
+	for _, core := range instances {
+		all = append(all, core)
+	}
+	instancesMu.Unlock()
+
+	for _, core := range all {
+		_ = core.Sync()
+	}
+}
+
+// flushTrigger wakes up the flush goroutine. It is buffered so that a
+// pending flush absorbs further triggers until it runs.
+var flushTrigger = make(chan struct{}, 1)
+
+var flushLoopOnce sync.Once
+
+// FlushAsync requests the global logger to be synced by a goroutine started
+// by the first InitGlobalLogger. It neither allocates, locks nor blocks, so
+// it is safe to call from a signal handler; the flush happens shortly after
+// it returns, or once InitGlobalLogger is called if it was not yet.
+func FlushAsync() {
+	select {
+	case flushTrigger <- struct{}{}:
+	default:
+		// a flush is already pending
+	}
+}
+
+// startFlushLoop starts the goroutine serving FlushAsync, once.
+func startFlushLoop() {
+	flushLoopOnce.Do(func() {
+		go flushLoop()
+	})
+}
+
+func flushLoop() {
+	for range flushTrigger {
+		Sync()
+	}
+}
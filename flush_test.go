@@ -0,0 +1,93 @@
+package easylog
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/logerror/easylog/pkg/option"
+)
+
+// syncRecorder is an output signaling its syncs on synced.
+type syncRecorder struct {
+	syncBuffer
+	synced chan struct{}
+}
+
+func newSyncRecorder() *syncRecorder {
+	return &syncRecorder{synced: make(chan struct{}, 1)}
+}
+
+func (r *syncRecorder) Sync() error {
+	select {
+	case r.synced <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func TestFlushAsync(t *testing.T) {
+	initGlobalBufferLogger(t)
+	out := newSyncRecorder()
+	SetOutput(out)
+
+	Info("pending")
+	FlushAsync()
+
+	select {
+	case <-out.synced:
+	case <-time.After(5 * time.Second):
+		t.Fatal("got no sync after FlushAsync")
+	}
+	if entry := decodeLine(t, out.String()); entry["msg"] != "pending" {
+		t.Errorf("got msg %v, want pending", entry["msg"])
+	}
+}
+
+func TestSyncAll(t *testing.T) {
+	first, _ := newBufferLogger(t)
+	second, _ := newBufferLogger(t)
+	outs := []*syncRecorder{newSyncRecorder(), newSyncRecorder()}
+	for i, l := range []*logger{first, second} {
+		l.consoleSyncer.swap(outs[i])
+		l.Info("pending")
+	}
+
+	SyncAll()
+
+	for i, out := range outs {
+		select {
+		case <-out.synced:
+		default:
+			t.Errorf("logger %d: got no sync", i)
+		}
+		if entry := decodeLine(t, out.String()); entry["msg"] != "pending" {
+			t.Errorf("logger %d: got msg %v, want pending", i, entry["msg"])
+		}
+	}
+}
+
+// registered reports whether the core registered as id is still registered.
+func registered(id uint64) bool {
+	instancesMu.Lock()
+	defer instancesMu.Unlock()
+	_, ok := instances[id]
+	return ok
+}
+
+func TestSyncAllReleasesLoggers(t *testing.T) {
+	setup(t)
+	id := func() uint64 {
+		l := initLogger(option.WithConsole(false))
+		l.Named("derived").Info("discarded")
+		return l.logger.Core().(*registeredCore).reg.id
+	}()
+
+	for i := 0; i < 50 && registered(id); i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if registered(id) {
+		t.Error("got the logger registered after it was collected")
+	}
+}
@@ -0,0 +1,113 @@
+package easylog
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// heartbeat logs a message whenever the cores sharing it have been idle for
+// the interval.
+type heartbeat struct {
+	interval time.Duration
+	msg      string
+	// lastWrite is the time of the last write in unix nanoseconds
+	lastWrite int64
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+var (
+	activeHeartbeatMu sync.Mutex
+	// activeHeartbeat is the running heartbeat: a logger built with a
+	// heartbeat replaces the one of the previous logger.
+	activeHeartbeat *heartbeat
+)
+
+func newHeartbeat(interval time.Duration, msg string) *heartbeat {
+	return &heartbeat{
+		interval:  interval,
+		msg:       msg,
+		lastWrite: time.Now().UnixNano(),
+		stop:      make(chan struct{}),
+	}
+}
+
+// start runs the heartbeat in the background, logging to lg, and stops the
+// previously active heartbeat.
+func (h *heartbeat) start(lg *zap.Logger) {
+	activeHeartbeatMu.Lock()
+	defer activeHeartbeatMu.Unlock()
+	if activeHeartbeat != nil {
+		activeHeartbeat.halt()
+	}
+	activeHeartbeat = h
+	go h.run(lg)
+}
+
+// run logs the heartbeat to lg each time no entry was written for the
+// interval, until the heartbeat is halted.
+func (h *heartbeat) run(lg *zap.Logger) {
+	timer := time.NewTimer(h.interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-h.stop:
+			return
+		case <-timer.C:
+		}
+		wait := h.interval - time.Since(time.Unix(0, atomic.LoadInt64(&h.lastWrite)))
+		if wait <= 0 {
+			lg.Info(h.msg)
+			wait = h.interval
+		}
+		timer.Reset(wait)
+	}
+}
+
+func (h *heartbeat) halt() {
+	h.stopOnce.Do(func() { close(h.stop) })
+}
+
+// StopHeartbeat stops the heartbeat set with option.WithHeartbeat. The
+// heartbeat runs again once a logger is built with the option.
+func StopHeartbeat() {
+	activeHeartbeatMu.Lock()
+	defer activeHeartbeatMu.Unlock()
+	if activeHeartbeat != nil {
+		activeHeartbeat.halt()
+		activeHeartbeat = nil
+	}
+}
+
+// heartbeatCore records the time of each write to its heartbeat.
+type heartbeatCore struct {
+	zapcore.Core
+	heartbeat *heartbeat
+}
+
+func newHeartbeatCore(core zapcore.Core, h *heartbeat) zapcore.Core {
+	return &heartbeatCore{Core: core, heartbeat: h}
+}
+
+func (c *heartbeatCore) With(fields []zapcore.Field) zapcore.Core {
+	return &heartbeatCore{
+		Core:      c.Core.With(fields),
+		heartbeat: c.heartbeat,
+	}
+}
+
+func (c *heartbeatCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *heartbeatCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	atomic.StoreInt64(&c.heartbeat.lastWrite, time.Now().UnixNano())
+	return c.Core.Write(ent, fields)
+}
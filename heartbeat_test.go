@@ -0,0 +1,52 @@
+package easylog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/logerror/easylog/pkg/option"
+)
+
+func TestWithHeartbeat(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithHeartbeat(50*time.Millisecond, "alive"))
+
+	// busy: each entry resets the idle time
+	for i := 0; i < 10; i++ {
+		l.Info("busy")
+		time.Sleep(10 * time.Millisecond)
+	}
+	if strings.Contains(buf.String(), "alive") {
+		t.Fatalf("got a heartbeat while logging: %q", buf.String())
+	}
+
+	time.Sleep(120 * time.Millisecond)
+	if !strings.Contains(buf.String(), "alive") {
+		t.Errorf("got no heartbeat after idling: %q", buf.String())
+	}
+}
+
+func TestStopHeartbeat(t *testing.T) {
+	_, buf := newBufferLogger(t, option.WithHeartbeat(20*time.Millisecond, "alive"))
+
+	StopHeartbeat()
+	time.Sleep(60 * time.Millisecond)
+
+	if out := buf.String(); out != "" {
+		t.Errorf("got %q after stopping the heartbeat, want nothing", out)
+	}
+}
+
+func TestHeartbeatReplaced(t *testing.T) {
+	_, first := newBufferLogger(t, option.WithHeartbeat(20*time.Millisecond, "first"))
+	_, second := newBufferLogger(t, option.WithHeartbeat(20*time.Millisecond, "second"))
+
+	time.Sleep(60 * time.Millisecond)
+
+	if out := first.String(); out != "" {
+		t.Errorf("got %q from the replaced heartbeat, want nothing", out)
+	}
+	if !strings.Contains(second.String(), "second") {
+		t.Errorf("got %q, want the heartbeat of the last logger", second.String())
+	}
+}
@@ -0,0 +1,188 @@
+package easylog
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/logerror/easylog/pkg/option"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// optionVars are the package variables of option, which every option sets
+// for good.
+var optionVars = []interface{}{
+	&option.LogFilePath, &option.LogFileSizeMB, &option.Compress, &option.MaxBackups, &option.MaxAge,
+	&option.LogLevel, &option.ConsoleRequired, &option.CallerSkip,
+	&option.TimePrecision, &option.TimeLayout, &option.EpochTime, &option.EpochMillis,
+	&option.EntryInterceptor, &option.DropEmptyMessage, &option.EmptyMessageLevel,
+	&option.ThroughputLimit, &option.ThroughputBurst, &option.ThroughputErrorBypass,
+	&option.SyncErrorHandler, &option.SchemaViolationHandler, &option.SuccessCounter,
+	&option.TraceSampledGatingLevel, &option.OmitEmptyMessage, &option.OtelSeverity,
+	&option.StructuredStack, &option.SamplingRatio, &option.DeadlineSamplingRate, &option.DeadlinePressureWindow, &option.Encoding, &option.CSVColumns,
+	&option.EncoderFieldKeys, &option.EncoderConfigFunc, &option.Fields, &option.SafeEncoding,
+	&option.UptimeField, &option.AutoPackageField, &option.MaxFields, &option.OSThreadField,
+	&option.LineChecksum, &option.LinePrefix, &option.ChannelSink,
+	&option.HeartbeatInterval, &option.HeartbeatMessage, &option.CrashFilePath,
+	&option.DeploymentTagField, &option.DeploymentTagEnv, &option.EnvFields, &option.CommitField,
+	&option.ErrorFilePath, &option.ErrorFileEncoderConfigFunc,
+	&option.DisableStacktrace, &option.StacktraceLevel,
+}
+
+// setup restores the options and the global loggers when t ends, so that
+// tests may configure them freely.
+func setup(t testing.TB) {
+	t.Helper()
+
+	saved := make([]reflect.Value, len(optionVars))
+	for i, p := range optionVars {
+		v := reflect.ValueOf(p).Elem()
+		saved[i] = reflect.New(v.Type()).Elem()
+		saved[i].Set(v)
+	}
+
+	prevLogger := globalLogger
+	prevRawLogger := globalRawLogger
+	prevSugaredLogger := globalSugaredLogger
+	prevFuncLogger := globalFuncLogger
+	prevFuncSugaredLogger := globalFuncSugaredLogger
+	prevLoggerLevel := globalLoggerLevel
+	prevLevel := globalLoggerLevel.Level()
+	prevOtelLogger := globalOtelLogger
+	prevOtelSugaredLogger := globalOtelSugaredLogger
+	extractorsMu.RLock()
+	prevExtractors := extractors
+	extractorsMu.RUnlock()
+	restoreZap := zap.ReplaceGlobals(zap.L())
+
+	t.Cleanup(func() {
+		for i, p := range optionVars {
+			reflect.ValueOf(p).Elem().Set(saved[i])
+		}
+		globalLogger = prevLogger
+		globalRawLogger = prevRawLogger
+		globalSugaredLogger = prevSugaredLogger
+		globalFuncLogger = prevFuncLogger
+		globalFuncSugaredLogger = prevFuncSugaredLogger
+		globalLoggerLevel = prevLoggerLevel
+		globalLoggerLevel.SetLevel(prevLevel)
+		globalOtelLogger = prevOtelLogger
+		globalOtelSugaredLogger = prevOtelSugaredLogger
+		extractorsMu.Lock()
+		extractors = prevExtractors
+		extractorsMu.Unlock()
+		StopHeartbeat()
+		restoreZap()
+	})
+}
+
+// syncBuffer is a bytes.Buffer safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// newBufferLogger builds a logger with opts whose console output is written
+// to the returned buffer.
+func newBufferLogger(t testing.TB, opts ...option.Option) (*logger, *syncBuffer) {
+	t.Helper()
+	setup(t)
+	l := initLogger(opts...)
+	buf := &syncBuffer{}
+	l.consoleSyncer.swap(zapcore.AddSync(buf))
+	return l, buf
+}
+
+// initGlobalBufferLogger sets up the global logger with opts, writing its
+// console output to the returned buffer.
+func initGlobalBufferLogger(t testing.TB, opts ...option.Option) *syncBuffer {
+	t.Helper()
+	setup(t)
+	InitGlobalLogger(opts...)
+	buf := &syncBuffer{}
+	SetOutput(buf)
+	return buf
+}
+
+// decodeLines decodes every JSON line of s.
+func decodeLines(t testing.TB, s string) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	sc := bufio.NewScanner(bytes.NewBufferString(s))
+	for sc.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(sc.Bytes(), &entry); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", sc.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// decodeLine decodes the only JSON line of s.
+func decodeLine(t testing.TB, s string) map[string]interface{} {
+	t.Helper()
+	entries := decodeLines(t, s)
+	if len(entries) != 1 {
+		t.Fatalf("got %d lines, want 1: %q", len(entries), s)
+	}
+	return entries[0]
+}
+
+// captureStdout returns what fn writes to os.Stdout, including through
+// loggers built by fn.
+func captureStdout(t testing.TB, fn func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() {
+		os.Stdout = stdout
+	}()
+
+	out := make(chan string)
+	go func() {
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+	fn()
+	_ = w.Close()
+	return <-out
+}
+
+// newTracer returns a tracer whose spans are recorded by the returned
+// recorder once ended.
+func newTracer(t testing.TB) (trace.Tracer, *tracetest.SpanRecorder) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+	})
+	return provider.Tracer("easylog"), recorder
+}
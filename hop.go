@@ -0,0 +1,20 @@
+package easylog
+
+import "context"
+
+const hopKey = "hop"
+
+type hopContextKey struct{}
+
+// IncrementHop returns ctx with its hop count increased by one. Services
+// call it at their boundary, so that G and GS log how deep in a call chain
+// an entry was written as the hop field.
+func IncrementHop(ctx context.Context) context.Context {
+	return context.WithValue(ctx, hopContextKey{}, Hop(ctx)+1)
+}
+
+// Hop returns the hop count of ctx, 0 if IncrementHop was never called.
+func Hop(ctx context.Context) int {
+	hop, _ := ctx.Value(hopContextKey{}).(int)
+	return hop
+}
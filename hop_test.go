@@ -0,0 +1,25 @@
+package easylog
+
+import (
+	"context"
+	"testing"
+)
+
+func TestIncrementHop(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+	ctx := IncrementHop(IncrementHop(context.Background()))
+
+	G(context.Background()).Info("origin")
+	G(ctx).Info("logger")
+	GS(ctx).Info("sugared")
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 3 {
+		t.Fatalf("got %d lines, want 3", len(entries))
+	}
+	for i, want := range []float64{0, 2, 2} {
+		if got := entries[i][hopKey]; got != want {
+			t.Errorf("%v: got hop %v, want %v", entries[i]["msg"], got, want)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package easylog
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const linkedTraceIdKey = "linked_trace_id"
+
+type linkedTraceIdContextKey struct{}
+
+// LinkedTrace returns ctx noting that the current operation refers to the
+// trace with the given hex id, e.g. when replaying an event. G and GS log it
+// as the linked_trace_id field, and the span of ctx, if recording, gets it
+// as an attribute of the same name since spans cannot be linked after they
+// started.
+func LinkedTrace(ctx context.Context, linkedTraceID string) context.Context {
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.SetAttributes(attribute.String(linkedTraceIdKey, linkedTraceID))
+	}
+	return context.WithValue(ctx, linkedTraceIdContextKey{}, linkedTraceID)
+}
+
+// LinkedTraceID returns the trace id stored in ctx by LinkedTrace.
+func LinkedTraceID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(linkedTraceIdContextKey{}).(string)
+	return id, ok
+}
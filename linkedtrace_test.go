@@ -0,0 +1,31 @@
+package easylog
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLinkedTrace(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+	tracer, recorder := newTracer(t)
+	const linked = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	ctx, span := tracer.Start(context.Background(), "replay")
+	ctx = LinkedTrace(ctx, linked)
+	G(ctx).Info("replayed")
+	span.End()
+
+	if entry := decodeLine(t, buf.String()); entry[linkedTraceIdKey] != linked {
+		t.Errorf("got linked_trace_id %v, want %s", entry[linkedTraceIdKey], linked)
+	}
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	for _, attr := range spans[0].Attributes() {
+		if attr.Key == linkedTraceIdKey && attr.Value.AsString() == linked {
+			return
+		}
+	}
+	t.Errorf("got attributes %v, want %s=%s", spans[0].Attributes(), linkedTraceIdKey, linked)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"os"
+	"strings"
 	"time"
 
 	"github.com/logerror/easylog/pkg/izap"
@@ -19,6 +20,11 @@ var (
 	globalRawLogger     *logger
 	globalSugaredLogger SugaredLogger
 
+	// globalFuncLogger and globalFuncSugaredLogger back the package-level
+	// logging functions, skipping the frame they add over the methods.
+	globalFuncLogger        Logger
+	globalFuncSugaredLogger SugaredLogger
+
 	globalLoggerLevel zap.AtomicLevel
 
 	globalOtelLogger        izap.Logger
@@ -57,14 +63,34 @@ type Logger interface {
 	Named(s string) Logger
 	With(fields ...Field) Logger
 	WithContext(ctx context.Context) izap.StdLogger
+	// SugarCtx returns the sugared logger bound to ctx, carrying its trace
+	// fields and recording to its span like WithContext.
+	SugarCtx(ctx context.Context) izap.StdSugaredLogger
 
 	Debug(msg string, fields ...Field)
 	Info(msg string, fields ...Field)
 	Warn(msg string, fields ...Field)
 	Error(msg string, fields ...Field)
 
+	// Check returns a CheckedEntry if logging a message at the given level
+	// is enabled, or nil otherwise.
+	Check(level option.Level, msg string) *zapcore.CheckedEntry
+
+	// WithLevel returns a logger writing entries at or above level,
+	// independently of the level of this logger.
+	WithLevel(level option.Level) Logger
+
+	// WithSampler returns a logger sampling its entries independently of
+	// this logger: per second and message, the first initial entries are
+	// logged and every thereafter-th entry after that. Changing the level
+	// of this logger lets every entry through for a second, after which the
+	// sampling starts over.
+	WithSampler(initial, thereafter int) Logger
+
 	Clone() Logger
 	Level() string
+	// Config returns the configuration the logger was built with.
+	Config() LoggerConfig
 	IsDebug() bool
 	Sync()
 
@@ -80,6 +106,13 @@ type logger struct {
 	sugaredLogger     *zap.SugaredLogger
 	otelLogger        izap.Logger
 	otelSugaredLogger izap.SugaredLogger
+
+	// consoleSyncer is the console output of a logger built by initLogger.
+	consoleSyncer *swappableSyncer
+	// sinks names the outputs of a logger built by initLogger.
+	sinks []string
+	// config is the configuration of a logger built by initLogger.
+	config *LoggerConfig
 }
 
 type sugaredLogger struct {
@@ -94,40 +127,37 @@ func InitGlobalLogger(options ...option.Option) Logger {
 	globalRawLogger = initLogger(options...)
 	globalLogger = globalRawLogger
 	globalSugaredLogger = globalLogger.SugaredLogger()
+	globalFuncLogger, globalFuncSugaredLogger = funcLoggers(globalLogger)
 	globalLoggerLevel = globalRawLogger.atomicLevel
 	globalOtelLogger = globalRawLogger.otelLogger
 	globalOtelSugaredLogger = globalRawLogger.otelSugaredLogger
 	zap.ReplaceGlobals(globalLogger.CoreLogger())
+	// the entries logged before are written to the configured outputs too
+	replayEarlyLogs(globalRawLogger.logger.Core())
+	startFlushLoop()
 	return globalRawLogger
 }
 
 func initLogger(options ...option.Option) *logger {
 	l := &logger{}
 
-	encoder := zapcore.EncoderConfig{
-		TimeKey:       "time",
-		LevelKey:      "level",
-		NameKey:       "name",
-		CallerKey:     "caller",
-		MessageKey:    "msg",
-		StacktraceKey: "stacktrace",
-		LineEnding:    zapcore.DefaultLineEnding,
-		EncodeLevel:   zapcore.LowercaseLevelEncoder,
-		EncodeTime: func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
-			encodeTimeLayout(t, "2006-01-02 15:04:05.000", enc)
-		},
-		EncodeDuration: zapcore.StringDurationEncoder,
-		EncodeCaller:   zapcore.ShortCallerEncoder,
-	}
-
 	// Apply additional options
 	for _, o := range options {
 		o.Apply()
 	}
 
-	consoleSyncer := zapcore.AddSync(os.Stdout)
+	encoder := newEncoderConfig()
+
+	if option.EncoderConfigFunc != nil {
+		option.EncoderConfigFunc(&encoder)
+	}
+
+	fileRequired := option.LogFilePath != "" && option.LogFileSizeMB != 0
+
+	consoleSyncer := newSwappableSyncer(zapcore.AddSync(os.Stdout))
+	l.consoleSyncer = consoleSyncer
 	multiWriteSyncer := zapcore.NewMultiWriteSyncer(consoleSyncer)
-	if option.LogFilePath != "" && option.LogFileSizeMB != 0 {
+	if fileRequired {
 		lumberjackLogger := &lumberjack.Logger{
 			Filename:   option.LogFilePath,
 			MaxSize:    option.LogFileSizeMB, // MaxSize in megabytes
@@ -136,7 +166,7 @@ func initLogger(options ...option.Option) *logger {
 			Compress:   option.Compress,      // Whether to compress the old log files
 		}
 
-		fileSyncer := zapcore.AddSync(lumberjackLogger)
+		fileSyncer := newErrorHandlingSyncer(zapcore.AddSync(lumberjackLogger), option.SyncErrorHandler)
 		if option.ConsoleRequired {
 			multiWriteSyncer = zapcore.NewMultiWriteSyncer(consoleSyncer, fileSyncer)
 		} else {
@@ -144,20 +174,318 @@ func initLogger(options ...option.Option) *logger {
 		}
 	}
 
-	core := zapcore.NewCore(
-		zapcore.NewJSONEncoder(encoder),
-		multiWriteSyncer,
-		ParseLevel(option.LogLevel),
-	)
+	if option.ConsoleRequired || !fileRequired {
+		l.sinks = append(l.sinks, "console")
+	}
+	if fileRequired {
+		l.sinks = append(l.sinks, "file:"+option.LogFilePath)
+	}
+
+	l.atomicLevel = zap.NewAtomicLevelAt(ParseLevel(option.LogLevel))
+
+	// the cores writing to an output share its CSV header
+	outputSyncer := newCSVHeaderSyncer(multiWriteSyncer, option.CSVColumns)
+
+	var core zapcore.Core
+	if !fileRequired && !option.ConsoleRequired {
+		// neither console nor file output is wanted, discard everything
+		core = zapcore.NewNopCore()
+	} else {
+		core = zapcore.NewCore(
+			newEncoder(encoder),
+			outputSyncer,
+			zapcore.DebugLevel,
+		)
+		if option.OmitEmptyMessage {
+			noMessageEncoder := encoder
+			noMessageEncoder.MessageKey = ""
+			core = newOmitEmptyMessageCore(core, zapcore.NewCore(
+				newEncoder(noMessageEncoder),
+				outputSyncer,
+				zapcore.DebugLevel,
+			))
+		}
+	}
+
+	if option.CrashFilePath != "" {
+		// the core syncs the file after every entry above error level
+		crashSyncer := newErrorHandlingSyncer(newLazyFileSyncer(option.CrashFilePath), option.SyncErrorHandler)
+		core = zapcore.NewTee(core, newLeveledWriteCore(zapcore.NewCore(
+			newEncoder(encoder),
+			newCSVHeaderSyncer(crashSyncer, option.CSVColumns),
+			zapcore.DPanicLevel,
+		)))
+		l.sinks = append(l.sinks, "crash:"+option.CrashFilePath)
+	}
+
+	if option.ErrorFilePath != "" {
+		errorEncoder := newEncoderConfig()
+		if option.ErrorFileEncoderConfigFunc != nil {
+			option.ErrorFileEncoderConfigFunc(&errorEncoder)
+		}
+		errorSyncer := newErrorHandlingSyncer(newLazyFileSyncer(option.ErrorFilePath), option.SyncErrorHandler)
+		core = zapcore.NewTee(core, newLeveledWriteCore(zapcore.NewCore(
+			newEncoder(errorEncoder),
+			newCSVHeaderSyncer(errorSyncer, option.CSVColumns),
+			zapcore.ErrorLevel,
+		)))
+		l.sinks = append(l.sinks, "error:"+option.ErrorFilePath)
+	}
+
+	if option.ChannelSink != nil {
+		core = zapcore.NewTee(core, newChannelCore(option.ChannelSink))
+	}
+
+	if b := teeEarlyLogs; b != nil {
+		teeEarlyLogs = nil
+		core = zapcore.NewTee(core, newEarlyCore(b))
+	}
+
+	// the level is enforced by levelCore so that it can be changed per logger
+	core = newLevelCore(core, l.atomicLevel)
+
+	// Wrappers rewriting entries on Write go first: the outer wrappers
+	// deciding in Check whether an entry is logged delegate to them, while
+	// they would bypass any Check of the cores they wrap.
+	var hb *heartbeat
+	if option.HeartbeatInterval > 0 {
+		hb = newHeartbeat(option.HeartbeatInterval, option.HeartbeatMessage)
+		core = newHeartbeatCore(core, hb)
+	}
+
+	if option.EntryInterceptor != nil {
+		core = newInterceptorCore(core, option.EntryInterceptor)
+	}
+
+	if option.OtelSeverity {
+		core = newInterceptorCore(core, appendOtelSeverity)
+	}
+
+	if option.UptimeField != "" {
+		core = newInterceptorCore(core, appendUptime(option.UptimeField, time.Now()))
+	}
 
-	l.logger = zap.New(core, zap.AddCaller(), zap.AddCallerSkip(option.CallerSkip), zap.AddStacktrace(zapcore.ErrorLevel))
+	if option.AutoPackageField {
+		core = newInterceptorCore(core, appendPackage)
+	}
+
+	if option.OSThreadField {
+		core = newInterceptorCore(core, appendOSThread)
+	}
+
+	if option.MaxFields > 0 {
+		core = newInterceptorCore(core, truncateFields(option.MaxFields))
+	}
+
+	if option.StructuredStack {
+		core = newStructuredStackCore(core)
+	}
+
+	core = newLevelFieldCore(core)
+
+	if option.SafeEncoding {
+		core = newSafeCore(core)
+	}
+
+	if option.TraceSampledGatingLevel != "" {
+		core = newSampledGatingCore(core, ParseLevel(option.TraceSampledGatingLevel))
+	}
+
+	if option.ThroughputLimit > 0 {
+		core = newThroughputCore(core, option.ThroughputLimit, option.ThroughputBurst, option.ThroughputErrorBypass)
+	}
+
+	if option.DropEmptyMessage || option.EmptyMessageLevel != "" {
+		core = newEmptyMessageCore(core, option.DropEmptyMessage, ParseLevel(option.EmptyMessageLevel))
+	}
+
+	// captures entries before any of the decisions above drops them
+	core = newDebugCaptureCore(core)
+	core = registerCore(core)
+
+	// option.CallerSkip accounts for the package-level functions, which add
+	// one frame over the methods of the logger
+	zapOptions := []zap.Option{zap.AddCaller(), zap.AddCallerSkip(option.CallerSkip - 1), zap.Fields(rootFields()...)}
+	if !option.DisableStacktrace {
+		zapOptions = append(zapOptions, zap.AddStacktrace(option.StacktraceLevel))
+	}
+	l.logger = zap.New(core, zapOptions...)
 	l.sugaredLogger = l.logger.Sugar()
-	l.otelLogger = otelzap.NewLogger(l.logger)
-	l.otelSugaredLogger = otelzap.NewSugaredLogger(l.sugaredLogger)
+	l.config = &LoggerConfig{
+		Encoding:                encoding(),
+		CallerSkip:              option.CallerSkip,
+		ThroughputLimit:         option.ThroughputLimit,
+		ThroughputBurst:         option.ThroughputBurst,
+		TraceSampledGatingLevel: option.TraceSampledGatingLevel,
+		Sampling:                sampling(),
+		Sinks:                   append([]string(nil), l.sinks...),
+	}
+	if fileRequired {
+		l.config.FilePath = option.LogFilePath
+	}
+	if hb != nil {
+		hb.start(l.logger.WithOptions(zap.WithCaller(false)))
+	}
+	otelOptions := []otelzap.Option{
+		otelzap.WithStructuredStack(option.StructuredStack),
+	}
+	if option.SamplingRatio >= 0 {
+		otelOptions = append(otelOptions, otelzap.WithSamplingProbabilityField(option.SamplingRatio))
+	}
+	if option.DeadlineSamplingRate > 1 {
+		otelOptions = append(otelOptions, otelzap.WithDeadlineAwareSampling(option.DeadlineSamplingRate, option.DeadlinePressureWindow))
+	}
+	l.otelLogger, l.otelSugaredLogger = otelLoggers(l.logger, otelOptions...)
 
 	return l
 }
 
+// newEncoder returns the encoder of the outputs of a logger built with cfg.
+func newEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	var enc zapcore.Encoder
+	switch encoding() {
+	case "console":
+		enc = newConsoleEncoder(cfg)
+	case "csv":
+		enc = newCSVEncoder(cfg, option.CSVColumns)
+	default:
+		enc = zapcore.NewJSONEncoder(cfg)
+	}
+	if newHash := newLineHash(option.LineChecksum); newHash != nil {
+		enc = newChecksumEncoder(enc, newHash, cfg.LineEnding)
+	}
+	if option.LinePrefix != "" {
+		// the prefix is outside of the line covered by the checksum
+		enc = newPrefixEncoder(enc, option.LinePrefix)
+	}
+	return enc
+}
+
+// encoding returns the encoding selected by option.Encoding, json if unknown
+// or csv without columns.
+func encoding() string {
+	switch {
+	case option.Encoding == "console":
+		return "console"
+	case option.Encoding == "csv" && len(option.CSVColumns) > 0:
+		return "csv"
+	}
+	return "json"
+}
+
+// NewLogger returns a Logger writing through lg, e.g. a logger of zaptest,
+// which reports the callers of its methods like the loggers of InitLogger.
+func NewLogger(lg *zap.Logger) Logger {
+	return newLogger(lg.WithOptions(zap.AddCaller(), zap.AddCallerSkip(option.CallerSkip-1)))
+}
+
+func newLogger(lg *zap.Logger) *logger {
+	otelLogger, otelSugaredLogger := otelLoggers(lg)
+	return &logger{
+		logger:            lg,
+		sugaredLogger:     lg.Sugar(),
+		otelLogger:        otelLogger,
+		otelSugaredLogger: otelSugaredLogger,
+	}
+}
+
+// otelLoggers returns the otel loggers of lg, a logger calibrated for the
+// methods of logger. The otel loggers are called directly instead, so they
+// skip one frame less. Span events skip the frames option.CallerSkip adds
+// for wrappers too, so that they record the same caller as the entries.
+func otelLoggers(lg *zap.Logger, opts ...otelzap.Option) (izap.Logger, izap.SugaredLogger) {
+	lg = lg.WithOptions(zap.AddCallerSkip(-1))
+	opts = append(opts[:len(opts):len(opts)], otelzap.WithCallerSkip(option.CallerSkip-2))
+	return otelzap.NewLogger(lg, opts...), otelzap.NewSugaredLogger(lg.Sugar(), opts...)
+}
+
+// rootFields returns the fields added to every entry of a logger.
+func rootFields() []Field {
+	fields := option.Fields[:len(option.Fields):len(option.Fields)]
+	if option.DeploymentTagField != "" && option.DeploymentTagEnv != "" {
+		if tag, ok := os.LookupEnv(option.DeploymentTagEnv); ok {
+			fields = append(fields, zap.String(option.DeploymentTagField, tag))
+		}
+	}
+	for _, f := range option.EnvFields {
+		if v, ok := os.LookupEnv(f.Env); ok {
+			fields = append(fields, zap.String(f.Key, v))
+		}
+	}
+	if option.CommitField {
+		if sha := buildCommit(); sha != "" {
+			fields = append(fields, zap.String(commitKey, sha))
+		}
+	}
+	return fields
+}
+
+// funcLoggers returns the loggers for the package-level functions to log
+// through l, reporting the caller of the functions rather than the functions.
+func funcLoggers(l Logger) (Logger, SugaredLogger) {
+	raw, ok := l.(*logger)
+	if !ok {
+		return l, l.SugaredLogger()
+	}
+	funcLogger := *raw
+	funcLogger.logger = raw.logger.WithOptions(zap.AddCallerSkip(1))
+	funcLogger.sugaredLogger = funcLogger.logger.Sugar()
+	return &funcLogger, funcLogger.SugaredLogger()
+}
+
+func newEncoderConfig() zapcore.EncoderConfig {
+	keys := option.EncoderFieldKeys
+	return zapcore.EncoderConfig{
+		TimeKey:       encoderKey(keys.TimeKey, "time"),
+		LevelKey:      encoderKey(keys.LevelKey, "level"),
+		NameKey:       encoderKey(keys.NameKey, "name"),
+		CallerKey:     encoderKey(keys.CallerKey, "caller"),
+		MessageKey:    encoderKey(keys.MessageKey, "msg"),
+		StacktraceKey: encoderKey(keys.StacktraceKey, "stacktrace"),
+		LineEnding:    zapcore.DefaultLineEnding,
+		EncodeLevel:   zapcore.LowercaseLevelEncoder,
+		// encodes the entry time and time fields such as zap.Time alike,
+		// so that both always share the layout
+		EncodeTime:     newTimeEncoder(),
+		EncodeDuration: zapcore.StringDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+}
+
+// encoderKey returns the key of an entry field set with option.EncoderFieldKeys,
+// def if unset and empty, which zap leaves out, for option.OmitKey.
+func encoderKey(key, def string) string {
+	switch key {
+	case "":
+		return def
+	case option.OmitKey:
+		return ""
+	}
+	return key
+}
+
+// newTimeEncoder returns the time encoder selected by option.EpochTime or
+// option.TimeLayout, the default layout if neither is set.
+func newTimeEncoder() zapcore.TimeEncoder {
+	if option.EpochTime {
+		if option.EpochMillis {
+			return zapcore.EpochMillisTimeEncoder
+		}
+		return zapcore.EpochTimeEncoder
+	}
+
+	layout := option.TimeLayout
+	if layout == "" {
+		layout = "2006-01-02 15:04:05"
+		if option.TimePrecision > 0 {
+			layout += "." + strings.Repeat("0", option.TimePrecision)
+		}
+	}
+	return func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+		encodeTimeLayout(t, layout, enc)
+	}
+}
+
 func ParseLevel(level string) option.Level {
 	lvl, ok := option.LevelMapping[level]
 	if ok {
@@ -181,9 +509,11 @@ func encodeTimeLayout(t time.Time, layout string, enc zapcore.PrimitiveArrayEnco
 }
 
 func init() {
-	globalRawLogger = initLogger()
+	earlyLogs = newEarlyBuffer(earlyLogsSize)
+	globalRawLogger = initLogger(earlyLogsOption{b: earlyLogs})
 	globalLogger = globalRawLogger
 	globalSugaredLogger = globalLogger.SugaredLogger()
+	globalFuncLogger, globalFuncSugaredLogger = funcLoggers(globalLogger)
 	globalLoggerLevel = globalRawLogger.atomicLevel
 	globalOtelLogger = globalRawLogger.otelLogger
 	globalOtelSugaredLogger = globalRawLogger.otelSugaredLogger
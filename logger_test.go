@@ -0,0 +1,357 @@
+package easylog
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/logerror/easylog/pkg/option"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestInitLoggerWithoutConsoleNorFileDiscards(t *testing.T) {
+	setup(t)
+
+	out := captureStdout(t, func() {
+		l := InitLogger(option.WithConsole(false))
+		l.Info("discarded")
+		l.Error("discarded too")
+		l.Sync()
+	})
+	if out != "" {
+		t.Errorf("got output %q, want none", out)
+	}
+}
+
+func TestInitLoggerWithConsoleWritesStdout(t *testing.T) {
+	setup(t)
+
+	out := captureStdout(t, func() {
+		l := InitLogger(option.WithConsole(true))
+		l.Info("written")
+		l.Sync()
+	})
+	if entry := decodeLine(t, out); entry["msg"] != "written" {
+		t.Errorf("got msg %v, want written", entry["msg"])
+	}
+}
+
+func TestWithEntryInterceptor(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithEntryInterceptor(func(_ zapcore.Entry, fields []zapcore.Field) []zapcore.Field {
+		return append(fields, zap.Bool("processed", true))
+	}))
+
+	l.Info("hello", zap.String("k", "v"))
+
+	entry := decodeLine(t, buf.String())
+	if entry["processed"] != true || entry["k"] != "v" {
+		t.Errorf("got %v, want processed=true and k=v", entry)
+	}
+}
+
+func TestWithDropEmptyMessage(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithDropEmptyMessage())
+
+	l.SugaredLogger().Info()
+	l.SugaredLogger().Info("kept")
+
+	if entry := decodeLine(t, buf.String()); entry["msg"] != "kept" {
+		t.Errorf("got msg %v, want kept", entry["msg"])
+	}
+}
+
+func TestWithEmptyMessageLevel(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithLogLevel("debug"), option.WithEmptyMessageLevel("debug"))
+
+	l.SugaredLogger().Info()
+	l.SugaredLogger().Info("kept")
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 2 {
+		t.Fatalf("got %d lines, want 2", len(entries))
+	}
+	if entries[0]["level"] != "debug" || entries[1]["level"] != "info" {
+		t.Errorf("got levels %v and %v, want debug and info", entries[0]["level"], entries[1]["level"])
+	}
+}
+
+func TestWithOmitEmptyMessage(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithOmitEmptyMessage(true))
+
+	l.SugarCtx(context.Background()).Infow("", "k", "v")
+	l.SugarCtx(context.Background()).Infow("kept", "k", "v")
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 2 {
+		t.Fatalf("got %d lines, want 2", len(entries))
+	}
+	if msg, ok := entries[0]["msg"]; ok || entries[0]["k"] != "v" {
+		t.Errorf("got msg %v and k=%v, want no msg and k=v", msg, entries[0]["k"])
+	}
+	if entries[1]["msg"] != "kept" {
+		t.Errorf("got msg %v, want kept", entries[1]["msg"])
+	}
+}
+
+func TestWithTimePrecision(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithTimePrecision(6))
+
+	l.Info("precise")
+
+	entry := decodeLine(t, buf.String())
+	if !regexp.MustCompile(`\.\d{6}$`).MatchString(entry["time"].(string)) {
+		t.Errorf("got time %v, want 6 fractional second digits", entry["time"])
+	}
+}
+
+func TestWithCrashFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crash.log")
+	l, _ := newBufferLogger(t, option.WithCrashFile(path))
+
+	l.Info("regular")
+	func() {
+		defer func() { recover() }()
+		l.logger.Panic("crashed")
+	}()
+
+	// the file is synced before the panic, so no Sync is needed
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := decodeLine(t, string(data))
+	if entry["msg"] != "crashed" || entry["level"] != "panic" {
+		t.Errorf("got %v, want the panic entry only", entry)
+	}
+}
+
+func TestWithDeploymentTag(t *testing.T) {
+	t.Setenv("DEPLOY_COLOR", "blue")
+	l, buf := newBufferLogger(t, option.WithFields(zap.String("region", "eu")), option.WithDeploymentTag("color", "DEPLOY_COLOR"))
+
+	l.Info("tagged")
+
+	entry := decodeLine(t, buf.String())
+	if entry["color"] != "blue" || entry["region"] != "eu" {
+		t.Errorf("got %v, want color=blue and region=eu", entry)
+	}
+}
+
+func TestWithDeploymentTagUnset(t *testing.T) {
+	os.Unsetenv("DEPLOY_COLOR_UNSET")
+	l, buf := newBufferLogger(t, option.WithDeploymentTag("color", "DEPLOY_COLOR_UNSET"))
+
+	l.Info("untagged")
+
+	if entry := decodeLine(t, buf.String()); entry["color"] != nil {
+		t.Errorf("got color %v, want none", entry["color"])
+	}
+}
+
+func TestWithErrorFileEncoder(t *testing.T) {
+	dir := t.TempDir()
+	mainPath, errorPath := filepath.Join(dir, "main.log"), filepath.Join(dir, "error.log")
+	l, _ := newBufferLogger(t, option.WithLogFilePath(mainPath), option.WithErrorFile(errorPath, func(cfg *zapcore.EncoderConfig) {
+		cfg.MessageKey = "message"
+	}))
+
+	l.Info("regular")
+	l.Error("failed")
+	l.Sync()
+
+	read := func(path string) []map[string]interface{} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return decodeLines(t, string(data))
+	}
+	if main := read(mainPath); len(main) != 2 || main[1]["msg"] != "failed" || main[1]["message"] != nil {
+		t.Errorf("got %v in the main file, want both entries with msg", main)
+	}
+	if errs := read(errorPath); len(errs) != 1 || errs[0]["message"] != "failed" || errs[0]["msg"] != nil {
+		t.Errorf("got %v in the error file, want the error entry with message", errs)
+	}
+}
+
+func TestWithoutStacktrace(t *testing.T) {
+	l, buf := newBufferLogger(t)
+	l.Error("traced")
+	if entry := decodeLine(t, buf.String()); entry["stacktrace"] == nil {
+		t.Fatal("got no stacktrace by default")
+	}
+
+	l, buf = newBufferLogger(t, option.WithoutStacktrace())
+	l.Error("untraced")
+	if entry := decodeLine(t, buf.String()); entry["stacktrace"] != nil {
+		t.Errorf("got stacktrace %v, want none", entry["stacktrace"])
+	}
+}
+
+func TestTimeFieldsShareLayout(t *testing.T) {
+	eventAt := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+	want := eventAt.Format(time.RFC3339)
+
+	l, buf := newBufferLogger(t, option.WithTimeLayout(time.RFC3339))
+	l.Info("event", zap.Time("event_at", eventAt))
+	entry := decodeLine(t, buf.String())
+	if _, err := time.Parse(time.RFC3339, entry["time"].(string)); err != nil {
+		t.Errorf("got time %v, want RFC 3339: %v", entry["time"], err)
+	}
+	if entry["event_at"] != want {
+		t.Errorf("got event_at %v, want %s", entry["event_at"], want)
+	}
+
+	l, buf = newBufferLogger(t, option.WithEncoding("console"), option.WithTimeLayout(time.RFC3339))
+	l.Info("event", zap.Time("event_at", eventAt))
+	out := buf.String()
+	if _, err := time.Parse(time.RFC3339, strings.SplitN(out, "\t", 2)[0]); err != nil {
+		t.Errorf("got %q, want the time as RFC 3339: %v", out, err)
+	}
+	if !strings.Contains(out, `"event_at": "`+want+`"`) {
+		t.Errorf("got %q, want event_at %s", out, want)
+	}
+}
+
+func TestWithEncodingConsole(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	l, buf := newBufferLogger(t, option.WithEncoding("console"), option.WithLogFilePath(path), option.WithConsole(true))
+
+	l.Info("readable", zap.String("k", "v"))
+	l.Sync()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for sink, out := range map[string]string{"console": buf.String(), "file": string(data)} {
+		columns := strings.Split(strings.TrimSuffix(out, "\n"), "\t")
+		if len(columns) < 4 || columns[1] != "info" || !strings.Contains(out, "readable") {
+			t.Errorf("%s: got %q, want tab-separated columns", sink, out)
+		}
+		if strings.HasPrefix(out, "{") {
+			t.Errorf("%s: got a JSON object %q", sink, out)
+		}
+	}
+}
+
+func TestWithEncodingUnknown(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithEncoding("yaml"))
+
+	l.Info("json")
+
+	if entry := decodeLine(t, buf.String()); entry["msg"] != "json" {
+		t.Errorf("got msg %v, want json", entry["msg"])
+	}
+}
+
+func TestWithLinePrefix(t *testing.T) {
+	const prefix = "@@LOG@@"
+	l, buf := newBufferLogger(t, option.WithLinePrefix(prefix))
+
+	l.Info("first")
+	l.Warn("second")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, prefix) {
+			t.Errorf("got %q, want the prefix %s", line, prefix)
+			continue
+		}
+		// consumers strip the prefix to parse the line
+		decodeLine(t, strings.TrimPrefix(line, prefix))
+	}
+}
+
+func TestWithTimeLayout(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithTimeLayout(time.RFC3339))
+
+	before := time.Now().Truncate(time.Second)
+	l.Info("stamped")
+
+	entry := decodeLine(t, buf.String())
+	stamp, err := time.Parse(time.RFC3339, entry["time"].(string))
+	if err != nil {
+		t.Fatalf("got time %v, want RFC 3339: %v", entry["time"], err)
+	}
+	if stamp.Before(before) || stamp.After(time.Now()) {
+		t.Errorf("got time %v, want about %v", stamp, before)
+	}
+}
+
+func TestWithTimeLayoutDefault(t *testing.T) {
+	l, buf := newBufferLogger(t)
+
+	l.Info("stamped")
+
+	entry := decodeLine(t, buf.String())
+	if _, err := time.ParseInLocation("2006-01-02 15:04:05.000", entry["time"].(string), time.Local); err != nil {
+		t.Errorf("got time %v, want the default layout: %v", entry["time"], err)
+	}
+}
+
+func TestWithEpochTime(t *testing.T) {
+	for _, millis := range []bool{false, true} {
+		l, buf := newBufferLogger(t, option.WithEpochTime(millis))
+
+		now := time.Now()
+		l.Info("stamped")
+
+		// within a second
+		want, tolerance := float64(now.UnixNano())/1e9, 1.0
+		if millis {
+			want, tolerance = want*1e3, 1e3
+		}
+		if got, _ := decodeLine(t, buf.String())["time"].(float64); got < want-tolerance || got > want+tolerance {
+			t.Errorf("millis=%v: got time %v, want about %v", millis, got, want)
+		}
+	}
+}
+
+func TestWithEncoderKeys(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithEncoderKeys(option.EncoderKeys{
+		TimeKey:   "@timestamp",
+		LevelKey:  "severity",
+		CallerKey: option.OmitKey,
+	}))
+
+	l.Info("renamed")
+
+	entry := decodeLine(t, buf.String())
+	if entry["@timestamp"] == nil || entry["severity"] != "info" {
+		t.Errorf("got %v, want @timestamp and severity", entry)
+	}
+	for _, key := range []string{"time", "level", "caller"} {
+		if v, ok := entry[key]; ok {
+			t.Errorf("got %s=%v, want it left out", key, v)
+		}
+	}
+	// unset keys keep their defaults
+	if entry["msg"] != "renamed" {
+		t.Errorf("got msg %v, want renamed", entry["msg"])
+	}
+}
+
+func TestWithStacktraceLevel(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithStacktraceLevel("warn"))
+	l.Info("plain")
+	l.Warn("traced")
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 2 || entries[0]["stacktrace"] != nil || entries[1]["stacktrace"] == nil {
+		t.Errorf("got %v, want a stacktrace on the warn entry only", entries)
+	}
+
+	l, buf = newBufferLogger(t, option.WithStacktraceLevel("none"))
+	l.Error("untraced")
+	if entry := decodeLine(t, buf.String()); entry["stacktrace"] != nil {
+		t.Errorf("got stacktrace %v with none, want it left out", entry["stacktrace"])
+	}
+}
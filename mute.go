@@ -0,0 +1,41 @@
+package easylog
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/logerror/easylog/pkg/izap"
+	otelzap "github.com/logerror/easylog/pkg/otel"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+var (
+	// mutedTraces is the set of the muted trace ids, in lowercase hex.
+	mutedTraces sync.Map
+
+	mutedLogger        izap.StdLogger        = otelzap.NewLogger(zap.NewNop())
+	mutedSugaredLogger izap.StdSugaredLogger = zap.NewNop().Sugar()
+)
+
+// MuteTrace makes G and GS discard every entry logged within the trace with
+// the given hex id, e.g. to silence a noisy request during an incident.
+func MuteTrace(traceID string) {
+	mutedTraces.Store(strings.ToLower(traceID), struct{}{})
+}
+
+// UnmuteTrace reverts MuteTrace for the trace with the given hex id.
+func UnmuteTrace(traceID string) {
+	mutedTraces.Delete(strings.ToLower(traceID))
+}
+
+// isMuted reports whether the trace of the span in ctx is muted.
+func isMuted(ctx context.Context) bool {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return false
+	}
+	_, muted := mutedTraces.Load(spanContext.TraceID().String())
+	return muted
+}
@@ -0,0 +1,31 @@
+package easylog
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMuteTrace(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+	tracer, _ := newTracer(t)
+	mutedCtx, muted := tracer.Start(context.Background(), "noisy")
+	defer muted.End()
+	ctx, span := tracer.Start(context.Background(), "quiet")
+	defer span.End()
+
+	MuteTrace(strings.ToUpper(muted.SpanContext().TraceID().String()))
+	G(mutedCtx).Error("muted")
+	GS(mutedCtx).Error("muted too")
+	G(ctx).Info("logged")
+	UnmuteTrace(muted.SpanContext().TraceID().String())
+	G(mutedCtx).Info("unmuted")
+
+	var msgs []string
+	for _, entry := range decodeLines(t, buf.String()) {
+		msgs = append(msgs, entry["msg"].(string))
+	}
+	if strings.Join(msgs, ",") != "logged,unmuted" {
+		t.Errorf("got messages %v, want logged and unmuted", msgs)
+	}
+}
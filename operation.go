@@ -0,0 +1,23 @@
+package easylog
+
+import "context"
+
+const operationKey = "operation"
+
+type operationContextKey struct{}
+
+// WithOperation returns ctx tagged with the logical operation name, such as
+// "charge_card", logged as the operation field by G and GS. Operations nest:
+// a name set within another operation is joined to it with a slash.
+func WithOperation(ctx context.Context, name string) context.Context {
+	if parent, ok := Operation(ctx); ok {
+		name = parent + "/" + name
+	}
+	return context.WithValue(ctx, operationContextKey{}, name)
+}
+
+// Operation returns the operation name stored in ctx by WithOperation.
+func Operation(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(operationContextKey{}).(string)
+	return name, ok
+}
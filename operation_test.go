@@ -0,0 +1,24 @@
+package easylog
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithOperation(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+	ctx := WithOperation(context.Background(), "checkout")
+
+	G(ctx).Info("outer")
+	GS(WithOperation(ctx, "charge_card")).Info("inner")
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 2 {
+		t.Fatalf("got %d lines, want 2", len(entries))
+	}
+	for i, want := range []string{"checkout", "checkout/charge_card"} {
+		if got := entries[i]["operation"]; got != want {
+			t.Errorf("%v: got operation %v, want %s", entries[i]["msg"], got, want)
+		}
+	}
+}
@@ -1,3 +1,9 @@
+// Package izap defines the logger interfaces shared by easylog and the otel
+// package, which other packages implement too, e.g. to wrap the otel
+// loggers. Adding a method to them would break those implementations, so
+// new capabilities are optional interfaces instead, such as NamedLogger and
+// DetachableLogger, with a helper like Detach falling back for the loggers
+// lacking them.
 package izap
 
 import (
@@ -68,3 +74,32 @@ type SugaredLogger interface {
 	WithOptions(opts ...zap.Option) SugaredLogger
 	Desugar() Logger
 }
+
+// NamedLogger is implemented by the Loggers able to derive a named logger
+// keeping their configuration, such as the loggers of the otel package.
+type NamedLogger interface {
+	Named(name string) Logger
+}
+
+// NamedSugaredLogger is implemented by the SugaredLoggers able to derive a
+// named logger keeping their configuration, such as the loggers of the otel
+// package.
+type NamedSugaredLogger interface {
+	Named(name string) SugaredLogger
+}
+
+// DetachableLogger is implemented by the StdLoggers recording events to a
+// span. Detach returns a logger keeping the trace fields but no longer
+// recording events to the span, e.g. for goroutines outliving a request.
+type DetachableLogger interface {
+	Detach() StdLogger
+}
+
+// Detach returns l detached from its span if it records events to one, see
+// DetachableLogger, else l itself.
+func Detach(l StdLogger) StdLogger {
+	if d, ok := l.(DetachableLogger); ok {
+		return d.Detach()
+	}
+	return l
+}
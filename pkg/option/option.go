@@ -2,6 +2,7 @@ package option
 
 import (
 	"strings"
+	"time"
 
 	"go.uber.org/zap/zapcore"
 )
@@ -33,7 +34,167 @@ var (
 
 	ConsoleRequired = true
 
+	// CallerSkip is the number of frames skipped to report the caller of
+	// the package-level logging functions. The methods of a logger add one
+	// frame less and skip one less.
 	CallerSkip = 2
+
+	// TimePrecision is the number of fractional second digits of the time
+	// field, between 0 and 9. It defaults to milliseconds.
+	TimePrecision = 3
+
+	// TimeLayout is the layout of the time field. Empty selects the default
+	// layout, "2006-01-02 15:04:05" with TimePrecision fractional digits.
+	TimeLayout string
+
+	// EpochTime encodes the time field as seconds, or milliseconds if
+	// EpochMillis is set, since the Unix epoch instead of with a layout.
+	EpochTime   bool
+	EpochMillis bool
+
+	// EntryInterceptor, when set, receives every entry with its fields before
+	// encoding and returns the fields that are actually written.
+	EntryInterceptor func(entry zapcore.Entry, fields []zapcore.Field) []zapcore.Field
+
+	// DropEmptyMessage drops entries whose message is empty, e.g. Info()
+	// called without arguments.
+	DropEmptyMessage bool
+
+	// EmptyMessageLevel is the level entries with an empty message are
+	// logged at instead of their own. Empty keeps the original level.
+	EmptyMessageLevel string
+
+	// ThroughputLimit is the number of lines per second allowed through the
+	// logger, 0 disables the limit. ThroughputBurst is the number of lines
+	// that may exceed the rate at once.
+	ThroughputLimit int
+	ThroughputBurst int
+
+	// ThroughputErrorBypass lets error and higher entries pass the
+	// throughput limit.
+	ThroughputErrorBypass bool
+
+	// SyncErrorHandler is called with every error writing or syncing the
+	// log file.
+	SyncErrorHandler func(err error)
+
+	// SchemaViolationHandler is called with the message and the missing
+	// fields of every entry of a schema logger missing required fields.
+	SchemaViolationHandler func(msg string, missing []string)
+
+	// SuccessCounter is called with the operation and outcome of every
+	// entry logged by easylog.Success.
+	SuccessCounter func(op string, ok bool)
+
+	// TraceSampledGatingLevel is the level below which entries logged with
+	// the context of an unsampled trace are dropped. Empty disables gating.
+	TraceSampledGatingLevel string
+
+	// OmitEmptyMessage leaves out the message key of entries whose message
+	// is empty.
+	OmitEmptyMessage bool
+
+	// OtelSeverity adds the OpenTelemetry severity_text and severity_number
+	// of the level to every entry.
+	OtelSeverity bool
+
+	// StructuredStack records stacktraces as arrays of frames, both in log
+	// entries and in span events.
+	StructuredStack bool
+
+	// SamplingRatio is the sampling probability logged as sampling_ratio by
+	// the context loggers when the trace state carries none. Negative
+	// disables the field.
+	SamplingRatio float64 = -1
+
+	// DeadlineSamplingRate is the rate the context loggers sample their
+	// entries with until the deadline of the context is closer than
+	// DeadlinePressureWindow. 0 or 1 disables the sampling.
+	DeadlineSamplingRate   int
+	DeadlinePressureWindow time.Duration
+
+	// Encoding is the encoding of the entries, "json", "console" or "csv"
+	// if CSVColumns is set. Any other value selects json.
+	Encoding = "json"
+
+	// CSVColumns are the fields written to the columns of the csv encoding.
+	CSVColumns []string
+
+	// EncoderFieldKeys renames the keys of the entry fields.
+	EncoderFieldKeys EncoderKeys
+
+	// EncoderConfigFunc customizes the encoder config of the logger.
+	EncoderConfigFunc func(cfg *zapcore.EncoderConfig)
+
+	// Fields are added to every entry of the logger.
+	Fields []zapcore.Field
+
+	// SafeEncoding recovers from panics while encoding fields.
+	SafeEncoding bool
+
+	// UptimeField is the key of a field holding the milliseconds elapsed
+	// since the logger was built. Empty disables it.
+	UptimeField string
+
+	// AutoPackageField adds the import path of the package logging an entry,
+	// derived from its caller, as the pkg field.
+	AutoPackageField bool
+
+	// MaxFields caps the fields passed when logging an entry. Zero disables
+	// the cap.
+	MaxFields int
+
+	// OSThreadField adds the id of the OS thread writing an entry as the tid
+	// field, on Linux only.
+	OSThreadField bool
+
+	// LineChecksum is the algorithm, crc32 or sha256, of the checksum
+	// appended to every line as the _checksum field. Empty disables it.
+	LineChecksum string
+
+	// LinePrefix is prepended to every line. Empty disables it.
+	LinePrefix string
+
+	// ChannelSink receives every entry with its fields. Entries are dropped
+	// when the channel is full.
+	ChannelSink chan<- LoggedEntry
+
+	// HeartbeatInterval is the idle time after which HeartbeatMessage is
+	// logged at info. Zero disables the heartbeat.
+	HeartbeatInterval time.Duration
+	HeartbeatMessage  string
+
+	// CrashFilePath is a file receiving the dpanic, panic and fatal entries
+	// in addition to the regular output. Empty disables it.
+	CrashFilePath string
+
+	// DeploymentTagField is the key of a field added to every entry, holding
+	// the value of the DeploymentTagEnv environment variable when the logger
+	// is built. The field is left out if the variable is unset.
+	DeploymentTagField string
+	DeploymentTagEnv   string
+
+	// EnvFields are added to every entry, each holding the value of its
+	// environment variable when the logger is built. Fields of unset
+	// variables are left out.
+	EnvFields []EnvField
+
+	// CommitField adds the commit the binary was built from to every entry.
+	CommitField bool
+
+	// ErrorFilePath is a file receiving the error and higher entries in
+	// addition to the regular output, encoded with the default encoder
+	// config customized by ErrorFileEncoderConfigFunc, if set, rather than
+	// with the config of the regular output. Empty disables it.
+	ErrorFilePath              string
+	ErrorFileEncoderConfigFunc func(cfg *zapcore.EncoderConfig)
+
+	// DisableStacktrace leaves out the stacktrace of every entry.
+	DisableStacktrace bool
+
+	// StacktraceLevel is the lowest level of the entries recorded with a
+	// stacktrace, unless DisableStacktrace is set.
+	StacktraceLevel = zapcore.ErrorLevel
 )
 
 type (
@@ -49,6 +210,22 @@ var (
 	FatalLevel = zapcore.FatalLevel
 )
 
+// LoggedEntry is an entry written by a logger along with its fields, those
+// the logger was derived with first.
+type LoggedEntry struct {
+	zapcore.Entry
+	Context []zapcore.Field
+}
+
+// ContextMap returns the fields of the entry keyed by name.
+func (e LoggedEntry) ContextMap() map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range e.Context {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
+
 var LevelMapping = map[string]Level{
 	DebugLevel.String(): DebugLevel,
 	InfoLevel.String():  InfoLevel,
@@ -123,6 +300,8 @@ type logConsoleOption struct {
 	Required bool
 }
 
+// WithConsole controls whether logs are written to stdout. When console output
+// is disabled and no log file is configured, the logger discards all entries.
 func WithConsole(required bool) Option {
 	return &logConsoleOption{
 		Required: required,
@@ -150,3 +329,730 @@ func WithCallerSkip(callerSkip int) Option {
 func (o *logCallerSkipOption) Apply() {
 	CallerSkip = o.CallerSkip
 }
+
+type logEntryInterceptorOption struct {
+	Interceptor func(entry zapcore.Entry, fields []zapcore.Field) []zapcore.Field
+}
+
+// WithEntryInterceptor registers fn to inspect and rewrite the fields of each
+// entry before it is encoded. Unlike zap.Hooks, fn sees the log-site fields
+// and may modify, drop or append to them.
+func WithEntryInterceptor(fn func(entry zapcore.Entry, fields []zapcore.Field) []zapcore.Field) Option {
+	return &logEntryInterceptorOption{
+		Interceptor: fn,
+	}
+}
+
+func (o *logEntryInterceptorOption) Apply() {
+	EntryInterceptor = o.Interceptor
+}
+
+type logEmptyMessageOption struct {
+	Drop  bool
+	Level string
+}
+
+// WithDropEmptyMessage drops entries whose message is empty.
+func WithDropEmptyMessage() Option {
+	return &logEmptyMessageOption{
+		Drop: true,
+	}
+}
+
+// WithEmptyMessageLevel logs entries whose message is empty at level
+// instead of the level they were written with.
+func WithEmptyMessageLevel(level string) Option {
+	return &logEmptyMessageOption{
+		Level: strings.ToLower(level),
+	}
+}
+
+func (o *logEmptyMessageOption) Apply() {
+	if o.Drop {
+		DropEmptyMessage = true
+	}
+	if o.Level != "" {
+		EmptyMessageLevel = o.Level
+	}
+}
+
+type logThroughputLimitOption struct {
+	LinesPerSecond int
+	Burst          int
+}
+
+// WithThroughputLimit limits the logger to linesPerSecond lines with bursts
+// of up to burst lines, dropping the entries exceeding it.
+func WithThroughputLimit(linesPerSecond int, burst int) Option {
+	return &logThroughputLimitOption{
+		LinesPerSecond: linesPerSecond,
+		Burst:          burst,
+	}
+}
+
+func (o *logThroughputLimitOption) Apply() {
+	ThroughputLimit = o.LinesPerSecond
+	ThroughputBurst = o.Burst
+}
+
+type logThroughputErrorBypassOption struct {
+	Bypass bool
+}
+
+// WithThroughputErrorBypass controls whether error and higher entries are
+// exempt from the throughput limit.
+func WithThroughputErrorBypass(bypass bool) Option {
+	return &logThroughputErrorBypassOption{
+		Bypass: bypass,
+	}
+}
+
+func (o *logThroughputErrorBypassOption) Apply() {
+	ThroughputErrorBypass = o.Bypass
+}
+
+type logSyncErrorHandlerOption struct {
+	Handler func(err error)
+}
+
+// WithSyncErrorHandler registers fn to be called when writing or syncing the
+// log file fails, e.g. to raise an alert when the device is full.
+func WithSyncErrorHandler(fn func(err error)) Option {
+	return &logSyncErrorHandlerOption{
+		Handler: fn,
+	}
+}
+
+func (o *logSyncErrorHandlerOption) Apply() {
+	SyncErrorHandler = o.Handler
+}
+
+type logSuccessCounterOption struct {
+	Counter func(op string, ok bool)
+}
+
+// WithSuccessCounter registers fn to be called by easylog.Success, e.g. to
+// increment a success or failure counter of op for SLO tracking.
+func WithSuccessCounter(fn func(op string, ok bool)) Option {
+	return &logSuccessCounterOption{
+		Counter: fn,
+	}
+}
+
+func (o *logSuccessCounterOption) Apply() {
+	SuccessCounter = o.Counter
+}
+
+type logSchemaViolationHandlerOption struct {
+	Handler func(msg string, missing []string)
+}
+
+// WithSchemaViolationHandler registers fn to be called with the message and
+// the missing fields of every entry of an easylog.SchemaLogger missing some
+// of its required fields, e.g. to fail a test with t.Errorf.
+func WithSchemaViolationHandler(fn func(msg string, missing []string)) Option {
+	return &logSchemaViolationHandlerOption{
+		Handler: fn,
+	}
+}
+
+func (o *logSchemaViolationHandlerOption) Apply() {
+	SchemaViolationHandler = o.Handler
+}
+
+type logTraceSampledGatingOption struct {
+	MinLevel string
+}
+
+// WithTraceSampledGating drops entries below minLevel that are logged
+// through a context-carrying logger (WithContext, G, GS) whose trace is not
+// sampled. Entries logged without a trace are not affected.
+func WithTraceSampledGating(minLevel string) Option {
+	return &logTraceSampledGatingOption{
+		MinLevel: strings.ToLower(minLevel),
+	}
+}
+
+func (o *logTraceSampledGatingOption) Apply() {
+	if o.MinLevel != "" {
+		TraceSampledGatingLevel = o.MinLevel
+	}
+}
+
+type logOmitEmptyMessageOption struct {
+	Omit bool
+}
+
+// WithOmitEmptyMessage controls whether the message key is left out of
+// entries with an empty message, e.g. Infow("", "k", "v").
+func WithOmitEmptyMessage(omit bool) Option {
+	return &logOmitEmptyMessageOption{
+		Omit: omit,
+	}
+}
+
+func (o *logOmitEmptyMessageOption) Apply() {
+	OmitEmptyMessage = o.Omit
+}
+
+type logOtelSeverityOption struct {
+	Enabled bool
+}
+
+// WithOtelSeverity controls whether entries carry the OpenTelemetry
+// severity_text and severity_number fields matching their level.
+func WithOtelSeverity(enabled bool) Option {
+	return &logOtelSeverityOption{
+		Enabled: enabled,
+	}
+}
+
+func (o *logOtelSeverityOption) Apply() {
+	OtelSeverity = o.Enabled
+}
+
+type logStructuredStackOption struct {
+	Enabled bool
+}
+
+// WithStructuredStack controls whether stacktraces are recorded as arrays of
+// "function file:line" frames, in the stack field of log entries and in
+// span log events, instead of a single newline-joined string.
+func WithStructuredStack(enabled bool) Option {
+	return &logStructuredStackOption{
+		Enabled: enabled,
+	}
+}
+
+func (o *logStructuredStackOption) Apply() {
+	StructuredStack = o.Enabled
+}
+
+type logSamplingProbabilityFieldOption struct {
+	Ratio float64
+}
+
+// WithSamplingProbabilityField adds the sampling ratio of the trace to the
+// entries of the context loggers as sampling_ratio: the probability carried
+// by the trace state if present, ratio, the ratio the tracer is configured
+// with, otherwise.
+func WithSamplingProbabilityField(ratio float64) Option {
+	return &logSamplingProbabilityFieldOption{
+		Ratio: ratio,
+	}
+}
+
+func (o *logSamplingProbabilityFieldOption) Apply() {
+	SamplingRatio = o.Ratio
+}
+
+type logDeadlineAwareSamplingOption struct {
+	NormalRate     int
+	PressureWindow time.Duration
+}
+
+// WithDeadlineAwareSampling makes the context loggers, such as those of G
+// and GS, log only one in normalRate entries, except once the deadline of
+// the context is closer than pressureWindow, from when on every entry is
+// logged. Entries at error level or above are never dropped. Entries are
+// counted per trace, or per context without a trace.
+func WithDeadlineAwareSampling(normalRate int, pressureWindow time.Duration) Option {
+	return &logDeadlineAwareSamplingOption{
+		NormalRate:     normalRate,
+		PressureWindow: pressureWindow,
+	}
+}
+
+func (o *logDeadlineAwareSamplingOption) Apply() {
+	DeadlineSamplingRate = o.NormalRate
+	DeadlinePressureWindow = o.PressureWindow
+}
+
+type logEncodingOption struct {
+	Encoding string
+}
+
+// WithEncoding selects the encoding of the entries: "json", the default, or
+// "console", the tab-separated human-readable format of zap meant for local
+// development. It applies to every output, and an unknown value selects
+// json.
+func WithEncoding(encoding string) Option {
+	return &logEncodingOption{
+		Encoding: encoding,
+	}
+}
+
+func (o *logEncodingOption) Apply() {
+	Encoding = o.Encoding
+}
+
+// OmitKey set as a key of EncoderKeys leaves the entry field out.
+const OmitKey = "-"
+
+// EncoderKeys are the keys of the entry fields. An empty key keeps the
+// default, OmitKey leaves the field out.
+type EncoderKeys struct {
+	TimeKey       string
+	LevelKey      string
+	CallerKey     string
+	MessageKey    string
+	NameKey       string
+	StacktraceKey string
+}
+
+type logEncoderKeysOption struct {
+	Keys EncoderKeys
+}
+
+// WithEncoderKeys renames the keys of the entry fields of every output, e.g.
+// to @timestamp and severity for an aggregator expecting them.
+func WithEncoderKeys(keys EncoderKeys) Option {
+	return &logEncoderKeysOption{
+		Keys: keys,
+	}
+}
+
+func (o *logEncoderKeysOption) Apply() {
+	EncoderFieldKeys = o.Keys
+}
+
+type logCSVEncodingOption struct {
+	Columns []string
+}
+
+// WithCSVEncoding encodes every entry as a CSV row, e.g. for a spreadsheet,
+// holding the fields named by columns in order, such as "level", "msg" and
+// "user_id". The keys of the time, level and message fields are those of
+// the encoder config. Fields an entry lacks are blank. Each output of a
+// logger starts with a header row naming the columns, even when appending to
+// an existing file.
+func WithCSVEncoding(columns ...string) Option {
+	return &logCSVEncodingOption{
+		Columns: columns,
+	}
+}
+
+func (o *logCSVEncodingOption) Apply() {
+	Encoding = "csv"
+	CSVColumns = o.Columns
+}
+
+type logEncoderConfigOption struct {
+	Func func(cfg *zapcore.EncoderConfig)
+}
+
+// WithEncoderConfig lets fn customize the encoder config, e.g. its keys or
+// encoders, before the logger is built.
+func WithEncoderConfig(fn func(cfg *zapcore.EncoderConfig)) Option {
+	return &logEncoderConfigOption{
+		Func: fn,
+	}
+}
+
+func (o *logEncoderConfigOption) Apply() {
+	EncoderConfigFunc = o.Func
+}
+
+type logFieldsOption struct {
+	Fields []zapcore.Field
+}
+
+// WithFields adds fields to every entry of the logger.
+func WithFields(fields ...zapcore.Field) Option {
+	return &logFieldsOption{
+		Fields: fields,
+	}
+}
+
+func (o *logFieldsOption) Apply() {
+	Fields = o.Fields
+}
+
+type logAdditionalFieldsOption struct {
+	Fields []zapcore.Field
+}
+
+// WithAdditionalFields adds fields to every entry of the logger, on top of
+// those of previous options rather than in their place, so that bundles of
+// options can be combined. A field replaces a previous one of the same key.
+func WithAdditionalFields(fields ...zapcore.Field) Option {
+	return &logAdditionalFieldsOption{
+		Fields: fields,
+	}
+}
+
+func (o *logAdditionalFieldsOption) Apply() {
+	merged := make([]zapcore.Field, 0, len(Fields)+len(o.Fields))
+	for _, f := range Fields {
+		if !hasFieldKey(o.Fields, f.Key) {
+			merged = append(merged, f)
+		}
+	}
+	Fields = append(merged, o.Fields...)
+}
+
+func hasFieldKey(fields []zapcore.Field, key string) bool {
+	for _, f := range fields {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+type logTimePrecisionOption struct {
+	Digits int
+}
+
+// WithTimePrecision sets the number of fractional second digits of the time
+// field, e.g. 3 for milliseconds, 6 for microseconds or 9 for nanoseconds.
+// Values outside 0 to 9 are clamped.
+func WithTimePrecision(digits int) Option {
+	return &logTimePrecisionOption{
+		Digits: digits,
+	}
+}
+
+func (o *logTimePrecisionOption) Apply() {
+	switch {
+	case o.Digits < 0:
+		TimePrecision = 0
+	case o.Digits > 9:
+		TimePrecision = 9
+	default:
+		TimePrecision = o.Digits
+	}
+}
+
+type logTimeLayoutOption struct {
+	Layout string
+}
+
+// WithTimeLayout sets the layout of the time field, e.g. time.RFC3339Nano,
+// replacing the default layout and WithTimePrecision. It overrides an
+// earlier WithEpochTime.
+func WithTimeLayout(layout string) Option {
+	return &logTimeLayoutOption{
+		Layout: layout,
+	}
+}
+
+func (o *logTimeLayoutOption) Apply() {
+	TimeLayout = o.Layout
+	EpochTime = false
+}
+
+type logEpochTimeOption struct {
+	Millis bool
+}
+
+// WithEpochTime encodes the time field as a number of seconds since the
+// Unix epoch, with fractional digits, or of milliseconds if millis is set.
+// It overrides an earlier WithTimeLayout.
+func WithEpochTime(millis bool) Option {
+	return &logEpochTimeOption{
+		Millis: millis,
+	}
+}
+
+func (o *logEpochTimeOption) Apply() {
+	EpochTime = true
+	EpochMillis = o.Millis
+	TimeLayout = ""
+}
+
+type logSafeEncodingOption struct {
+	Enabled bool
+}
+
+// WithSafeEncoding controls whether panics raised while encoding fields,
+// e.g. by a typed-nil ObjectMarshaler, are recovered. The entry is then
+// written with an encode_error field in place of its fields.
+func WithSafeEncoding(enabled bool) Option {
+	return &logSafeEncodingOption{
+		Enabled: enabled,
+	}
+}
+
+func (o *logSafeEncodingOption) Apply() {
+	SafeEncoding = o.Enabled
+}
+
+type logUptimeFieldOption struct {
+	FieldName string
+}
+
+// WithUptimeField adds the milliseconds elapsed since the logger was built
+// to every entry, keyed fieldName or uptime_ms if empty.
+func WithUptimeField(fieldName string) Option {
+	if fieldName == "" {
+		fieldName = "uptime_ms"
+	}
+	return &logUptimeFieldOption{
+		FieldName: fieldName,
+	}
+}
+
+func (o *logUptimeFieldOption) Apply() {
+	UptimeField = o.FieldName
+}
+
+type logAutoPackageFieldOption struct{}
+
+// WithAutoPackageField adds the import path of the package logging each entry
+// as the pkg field, e.g. to filter the output of one package. It is derived
+// from the caller zap already records, so entries without a caller carry no
+// pkg field.
+func WithAutoPackageField() Option {
+	return &logAutoPackageFieldOption{}
+}
+
+func (o *logAutoPackageFieldOption) Apply() {
+	AutoPackageField = true
+}
+
+type logMaxFieldsOption struct {
+	N int
+}
+
+// WithMaxFields keeps the first n fields passed when logging an entry and
+// replaces the others with a fields_truncated field holding their number.
+// Fields added with With are not counted.
+func WithMaxFields(n int) Option {
+	return &logMaxFieldsOption{
+		N: n,
+	}
+}
+
+func (o *logMaxFieldsOption) Apply() {
+	MaxFields = o.N
+}
+
+type logLineChecksumOption struct {
+	Algo string
+}
+
+// WithLineChecksum appends to every line the hex checksum of the rest of
+// the line, computed with algo, "crc32" or "sha256", as the _checksum field;
+// any other algo disables it. The checksum covers the line as written, so it
+// is always the last field: it only validates if the line is shipped byte
+// for byte, without reordering or reformatting its fields. To validate a
+// JSON line, remove `,"_checksum":"<hex>"` before its closing brace and hash
+// the rest without the line ending.
+func WithLineChecksum(algo string) Option {
+	return &logLineChecksumOption{
+		Algo: algo,
+	}
+}
+
+func (o *logLineChecksumOption) Apply() {
+	LineChecksum = o.Algo
+}
+
+type logLinePrefixOption struct {
+	Prefix string
+}
+
+// WithLinePrefix prepends prefix, e.g. "@@LOG@@", to every line so that a
+// demultiplexer can tell the lines of the logger apart from other output on
+// a shared stream. The lines are no longer valid JSON: consumers must strip
+// the prefix before parsing them, or validating their WithLineChecksum
+// checksum.
+func WithLinePrefix(prefix string) Option {
+	return &logLinePrefixOption{
+		Prefix: prefix,
+	}
+}
+
+func (o *logLinePrefixOption) Apply() {
+	LinePrefix = o.Prefix
+}
+
+type logOSThreadFieldOption struct{}
+
+// WithOSThreadField adds the id of the OS thread writing each entry as the
+// tid field, e.g. to debug cgo or syscall issues. Entries are written by
+// the goroutine logging them, so the id is that of its thread at that time:
+// goroutines move between threads unless runtime.LockOSThread pins them.
+// Thread ids are only available on Linux, elsewhere the field is left out.
+func WithOSThreadField() Option {
+	return &logOSThreadFieldOption{}
+}
+
+func (o *logOSThreadFieldOption) Apply() {
+	OSThreadField = true
+}
+
+type logChannelSinkOption struct {
+	Ch chan<- LoggedEntry
+}
+
+// WithChannelSink sends every entry with its fields to ch for in-process
+// consumption. Sending never blocks: entries are dropped while ch is full.
+func WithChannelSink(ch chan<- LoggedEntry) Option {
+	return &logChannelSinkOption{
+		Ch: ch,
+	}
+}
+
+func (o *logChannelSinkOption) Apply() {
+	ChannelSink = o.Ch
+}
+
+type logHeartbeatOption struct {
+	Interval time.Duration
+	Msg      string
+}
+
+// WithHeartbeat logs msg at info whenever the logger has not written any
+// entry for interval, to tell an idle process from a stuck one. Only the
+// last logger built with a heartbeat runs it, until easylog.StopHeartbeat.
+func WithHeartbeat(interval time.Duration, msg string) Option {
+	return &logHeartbeatOption{
+		Interval: interval,
+		Msg:      msg,
+	}
+}
+
+func (o *logHeartbeatOption) Apply() {
+	HeartbeatInterval = o.Interval
+	HeartbeatMessage = o.Msg
+}
+
+type logCrashFileOption struct {
+	Path string
+}
+
+// WithCrashFile additionally writes dpanic, panic and fatal entries to the
+// file at path, which is synced before the process exits or panics, to keep
+// the last words of a crashed process in one small file. The file is only
+// created on the first such entry.
+func WithCrashFile(path string) Option {
+	return &logCrashFileOption{
+		Path: path,
+	}
+}
+
+func (o *logCrashFileOption) Apply() {
+	CrashFilePath = o.Path
+}
+
+type logDeploymentTagOption struct {
+	FieldName string
+	EnvVar    string
+}
+
+// WithDeploymentTag adds the value of the environment variable envVar, such
+// as DEPLOY_COLOR, to every entry as fieldName, next to the fields of
+// WithFields. Nothing is added if the variable is unset.
+func WithDeploymentTag(fieldName, envVar string) Option {
+	return &logDeploymentTagOption{
+		FieldName: fieldName,
+		EnvVar:    envVar,
+	}
+}
+
+func (o *logDeploymentTagOption) Apply() {
+	DeploymentTagField = o.FieldName
+	DeploymentTagEnv = o.EnvVar
+}
+
+// EnvField is a field holding the value of the environment variable Env.
+type EnvField struct {
+	Key string
+	Env string
+}
+
+type logEnvFieldOption struct {
+	Field EnvField
+}
+
+// WithEnvField adds the value of the environment variable envVar, as read
+// when the logger is built, to every entry as fieldName, on top of the
+// fields of previous options. It replaces a previous env field of the same
+// name. Nothing is added if the variable is unset.
+func WithEnvField(fieldName, envVar string) Option {
+	return &logEnvFieldOption{
+		Field: EnvField{Key: fieldName, Env: envVar},
+	}
+}
+
+func (o *logEnvFieldOption) Apply() {
+	fields := make([]EnvField, 0, len(EnvFields)+1)
+	for _, f := range EnvFields {
+		if f.Key != o.Field.Key {
+			fields = append(fields, f)
+		}
+	}
+	EnvFields = append(fields, o.Field)
+}
+
+type logCommitFieldOption struct{}
+
+// WithCommitField adds the commit set by easylog.SetCommit to every entry as
+// commit, defaulting to the VCS revision recorded by the go command in the
+// build info. Nothing is added if neither is available.
+func WithCommitField() Option {
+	return &logCommitFieldOption{}
+}
+
+func (o *logCommitFieldOption) Apply() {
+	CommitField = true
+}
+
+type logErrorFileOption struct {
+	Path              string
+	EncoderConfigFunc func(cfg *zapcore.EncoderConfig)
+}
+
+// WithErrorFile additionally writes error and higher entries to the file at
+// path. Its encoder config is independent of WithEncoderConfig: it starts
+// from the defaults and is customized by fn, if not nil, e.g. to give the
+// file read by humans other keys than the output read by machines.
+func WithErrorFile(path string, fn func(cfg *zapcore.EncoderConfig)) Option {
+	return &logErrorFileOption{
+		Path:              path,
+		EncoderConfigFunc: fn,
+	}
+}
+
+func (o *logErrorFileOption) Apply() {
+	ErrorFilePath = o.Path
+	ErrorFileEncoderConfigFunc = o.EncoderConfigFunc
+}
+
+type logWithoutStacktraceOption struct{}
+
+// WithoutStacktrace leaves out the stacktrace zap records for entries at or
+// above the stacktrace level, which is costly to capture and noisy to read.
+func WithoutStacktrace() Option {
+	return &logWithoutStacktraceOption{}
+}
+
+func (o *logWithoutStacktraceOption) Apply() {
+	DisableStacktrace = true
+}
+
+type logStacktraceLevelOption struct {
+	Level string
+}
+
+// WithStacktraceLevel records a stacktrace for entries at or above level,
+// e.g. "warn", instead of error and above. "none" leaves out every
+// stacktrace, as WithoutStacktrace does. An unknown level is ignored.
+func WithStacktraceLevel(level string) Option {
+	return &logStacktraceLevelOption{
+		Level: level,
+	}
+}
+
+func (o *logStacktraceLevelOption) Apply() {
+	if o.Level == "none" {
+		DisableStacktrace = true
+		return
+	}
+	if lvl, ok := LevelMapping[o.Level]; ok {
+		StacktraceLevel = lvl
+		DisableStacktrace = false
+	}
+}
@@ -0,0 +1,41 @@
+package otel
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type b3ContextKey struct{}
+
+type b3Ids struct {
+	traceId string
+	spanId  string
+}
+
+// ContextWithB3 stores the B3 trace and span ids, as propagated in the
+// x-b3-traceid and x-b3-spanid headers, in ctx. They are used by WithContext
+// when WithB3Fallback is enabled and ctx carries no valid otel span.
+func ContextWithB3(ctx context.Context, traceId, spanId string) context.Context {
+	return context.WithValue(ctx, b3ContextKey{}, b3Ids{traceId: traceId, spanId: spanId})
+}
+
+// b3Fields returns the trace fields read from the B3 ids in ctx, if any.
+func b3Fields(ctx context.Context, cfg config) []zap.Field {
+	if !cfg.B3Fallback || ctx == nil {
+		return nil
+	}
+	ids, ok := ctx.Value(b3ContextKey{}).(b3Ids)
+	if !ok {
+		return nil
+	}
+
+	var fields []zap.Field
+	if cfg.LogTraceId && ids.traceId != "" {
+		fields = append(fields, zap.String(defaultTraceIdKey, shortId(ids.traceId, cfg)))
+	}
+	if cfg.LogSpanId && ids.spanId != "" {
+		fields = append(fields, zap.String(defaultSpanIdKey, shortId(ids.spanId, cfg)))
+	}
+	return fields
+}
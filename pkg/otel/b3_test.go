@@ -0,0 +1,40 @@
+package otel
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithB3Fallback(t *testing.T) {
+	lg, logs := newObserved()
+	l := NewLogger(lg, WithB3Fallback(), WithLogSpanId(true))
+
+	ctx := ContextWithB3(context.Background(), "463ac35c9f6413ad48485a3953bb6124", "a2fb4a1d1a96d312")
+	l.WithContext(ctx).Info("b3")
+	l.Sugar().WithContext(ctx).Infow("b3 too")
+	WithContext(ctx, lg, WithB3Fallback(), WithLogSpanId(true)).Info("b3 again")
+
+	if logs.Len() != 3 {
+		t.Fatalf("got %d entries, want 3", logs.Len())
+	}
+	for _, entry := range logs.All() {
+		if fieldValue(entry, defaultTraceIdKey) != "463ac35c9f6413ad48485a3953bb6124" {
+			t.Errorf("%s: got trace_id %v", entry.Message, fieldValue(entry, defaultTraceIdKey))
+		}
+		if fieldValue(entry, defaultSpanIdKey) != "a2fb4a1d1a96d312" {
+			t.Errorf("%s: got span_id %v", entry.Message, fieldValue(entry, defaultSpanIdKey))
+		}
+	}
+}
+
+func TestWithoutB3Fallback(t *testing.T) {
+	lg, logs := newObserved()
+	l := NewLogger(lg)
+
+	ctx := ContextWithB3(context.Background(), "463ac35c9f6413ad48485a3953bb6124", "a2fb4a1d1a96d312")
+	l.WithContext(ctx).Info("plain")
+
+	if v := fieldValue(logs.All()[0], defaultTraceIdKey); v != nil {
+		t.Errorf("got trace_id %v, want none", v)
+	}
+}
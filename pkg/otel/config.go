@@ -1,17 +1,45 @@
 package otel
 
-import "go.uber.org/zap/zapcore"
+import (
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
 
 // config is used to configure the iris middleware.
 type config struct {
-	LogTraceId bool
-	LogSpanId  bool
-	LogSampled bool
+	LogTraceId      bool
+	LogSpanId       bool
+	LogSampled      bool
+	LogSpanName     bool
+	LogParentSpanId bool
+
+	SpanAttributeFields []string
 
 	LogLevel         zapcore.Level
 	ErrorStatusLevel zapcore.Level
 	CallerDepth      int8
 	CallerSkip       uint8
+	StructuredStack  bool
+
+	SkipSpanAttribute string
+	B3Fallback        bool
+	ShortIds          bool
+
+	TraceFieldsMinLevel zapcore.Level
+
+	DeadlineSamplingRate   int
+	DeadlinePressureWindow time.Duration
+
+	Enrichers []Enricher
+
+	LogSamplingRatio bool
+	SamplingRatio    float64
+}
+
+// deadlineSampling reports whether WithDeadlineAwareSampling is in effect.
+func (c config) deadlineSampling() bool {
+	return c.DeadlineSamplingRate > 1
 }
 
 // Option specifies instrumentation configuration options.
@@ -43,6 +71,66 @@ func WithLogSampled(enabled bool) Option {
 	})
 }
 
+// WithSpanNameField logs the name of the span as span_name. The name can
+// only be read from spans exposing it, such as sdk spans.
+func WithSpanNameField(enabled bool) Option {
+	return optionFunc(func(cfg *config) {
+		cfg.LogSpanName = enabled
+	})
+}
+
+// WithParentSpanIdField logs the span id of the parent span as
+// parent_span_id, omitted for root spans. The parent can only be read from
+// spans exposing it, such as sdk spans.
+func WithParentSpanIdField(enabled bool) Option {
+	return optionFunc(func(cfg *config) {
+		cfg.LogParentSpanId = enabled
+	})
+}
+
+// WithSpanAttributesAsFields logs the span attributes named by keys, such as
+// http.method, as fields. Attributes can only be read from spans exposing
+// them, such as sdk spans.
+func WithSpanAttributesAsFields(keys ...string) Option {
+	return optionFunc(func(cfg *config) {
+		cfg.SpanAttributeFields = keys
+	})
+}
+
+// WithSamplingProbabilityField logs the sampling ratio of the trace as
+// sampling_ratio: the probability carried by the ot entry of the trace state,
+// if any, ratio otherwise.
+func WithSamplingProbabilityField(ratio float64) Option {
+	return optionFunc(func(cfg *config) {
+		cfg.LogSamplingRatio = true
+		cfg.SamplingRatio = ratio
+	})
+}
+
+// WithTraceFieldsMinLevel only attaches the trace fields, such as trace_id
+// and span_id, to entries at or above level, e.g. "warn" to keep them off
+// debug and info lines. An unknown level is ignored.
+func WithTraceFieldsMinLevel(level string) Option {
+	return optionFunc(func(cfg *config) {
+		if lvl, err := zapcore.ParseLevel(level); err == nil {
+			cfg.TraceFieldsMinLevel = lvl
+		}
+	})
+}
+
+// WithDeadlineAwareSampling makes loggers bound to a context log only one in
+// normalRate entries, except once the deadline of the context is closer than
+// pressureWindow, from when on every entry is logged to help debugging slow
+// operations. Contexts without a deadline are always sampled, and entries at
+// error level or above are never dropped. Entries are counted per trace, or per context for contexts without a trace, across all
+// the loggers bound to it.
+func WithDeadlineAwareSampling(normalRate int, pressureWindow time.Duration) Option {
+	return optionFunc(func(cfg *config) {
+		cfg.DeadlineSamplingRate = normalRate
+		cfg.DeadlinePressureWindow = pressureWindow
+	})
+}
+
 func WithLogLevel(logLevel zapcore.Level) Option {
 	return optionFunc(func(cfg *config) {
 		cfg.LogLevel = logLevel
@@ -65,6 +153,18 @@ func WithCallerDepth(depth int) Option {
 	})
 }
 
+// WithStructuredStack records the stack of span log events as an array of
+// "function file:line" frames instead of a single newline-joined string.
+func WithStructuredStack(enabled bool) Option {
+	return optionFunc(func(cfg *config) {
+		cfg.StructuredStack = enabled
+	})
+}
+
+// WithCallerSkip skips skip more frames when recording the caller of span
+// log events, for wrappers of the logger. The zap logger passed to NewLogger
+// or NewSugaredLogger must skip the same frames with zap.AddCallerSkip so
+// that entries and span events report the same caller.
 func WithCallerSkip(skip int) Option {
 	if skip > 0 {
 		return optionFunc(func(cfg *config) {
@@ -73,3 +173,29 @@ func WithCallerSkip(skip int) Option {
 	}
 	return optionFunc(func(c *config) {})
 }
+
+// WithSkipSpanAttribute suppresses logging for spans carrying the given
+// attribute key. A bool attribute only suppresses when true; any other
+// type suppresses when present. Only spans exposing their attributes
+// (e.g. sdk spans) can be inspected.
+func WithSkipSpanAttribute(key string) Option {
+	return optionFunc(func(cfg *config) {
+		cfg.SkipSpanAttribute = key
+	})
+}
+
+// WithB3Fallback reads the trace and span ids stored by ContextWithB3 when
+// the context carries no valid otel span, for services propagating B3.
+func WithB3Fallback() Option {
+	return optionFunc(func(cfg *config) {
+		cfg.B3Fallback = true
+	})
+}
+
+// WithShortIDs truncates the logged trace and span ids to their last 8 hex
+// chars, which keeps console output readable.
+func WithShortIDs(enabled bool) Option {
+	return optionFunc(func(cfg *config) {
+		cfg.ShortIds = enabled
+	})
+}
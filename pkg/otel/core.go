@@ -0,0 +1,194 @@
+package otel
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// withTraceFields derives a logger from zLogger carrying the trace fields,
+// restricted to the entries at or above the configured minimum level.
+func withTraceFields(zLogger *zap.Logger, fields []zap.Field, cfg config) *zap.Logger {
+	if cfg.TraceFieldsMinLevel <= zapcore.DebugLevel {
+		return zLogger.With(fields...)
+	}
+	return zLogger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return newTraceFieldsCore(core, fields, cfg.TraceFieldsMinLevel)
+	}))
+}
+
+// traceFieldsCore writes entries at or above minLevel through a core derived
+// with the trace fields, and the others through one derived only with the
+// marker fields, which are never encoded but may be read by wrapped cores.
+type traceFieldsCore struct {
+	zapcore.Core
+	traced   zapcore.Core
+	minLevel zapcore.Level
+}
+
+func newTraceFieldsCore(core zapcore.Core, fields []zap.Field, minLevel zapcore.Level) zapcore.Core {
+	var markers []zap.Field
+	for _, f := range fields {
+		if f.Type == zapcore.SkipType {
+			markers = append(markers, f)
+		}
+	}
+	return &traceFieldsCore{
+		Core:     core.With(markers),
+		traced:   core.With(fields),
+		minLevel: minLevel,
+	}
+}
+
+func (c *traceFieldsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &traceFieldsCore{
+		Core:     c.Core.With(fields),
+		traced:   c.traced.With(fields),
+		minLevel: c.minLevel,
+	}
+}
+
+func (c *traceFieldsCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level >= c.minLevel {
+		return c.traced.Check(ent, ce)
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// withDeadlineSampling derives a logger from zLogger sampling its entries
+// according to the deadline of ctx, if WithDeadlineAwareSampling is in effect.
+func withDeadlineSampling(zLogger *zap.Logger, ctx context.Context, cfg config) *zap.Logger {
+	if !cfg.deadlineSampling() {
+		return zLogger
+	}
+	deadline, hasDeadline := ctx.Deadline()
+	state := &deadlineSamplerState{
+		rate:        uint64(cfg.DeadlineSamplingRate),
+		window:      cfg.DeadlinePressureWindow,
+		deadline:    deadline,
+		hasDeadline: hasDeadline,
+		count:       deadlineCount(ctx),
+	}
+	return zLogger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &deadlineSamplerCore{Core: core, state: state}
+	}))
+}
+
+const (
+	// deadlineCounterIdle is the time after which an unused counter is
+	// dropped.
+	deadlineCounterIdle = time.Minute
+	// deadlineCounterSweep is the number of counters created between two
+	// sweeps of the unused ones.
+	deadlineCounterSweep = 1024
+)
+
+var (
+	// deadlineCounters are the *deadlineCounter of the traces, or of the
+	// contexts without a trace, keyed by trace id or done channel, so that
+	// all loggers bound to a request sample as one.
+	deadlineCounters        sync.Map
+	deadlineCountersCreated uint64
+
+	// globalDeadlineCount counts the entries of the contexts with neither
+	// a trace nor a done channel.
+	globalDeadlineCount uint64
+)
+
+type deadlineCounter struct {
+	count    uint64
+	lastUsed int64
+	done     <-chan struct{}
+}
+
+// deadlineCount returns the entry count shared by the loggers bound to ctx,
+// its trace or, without a trace, the contexts sharing its done channel.
+func deadlineCount(ctx context.Context) *uint64 {
+	var key interface{}
+	done := ctx.Done()
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		key = spanContext.TraceID()
+	} else if done != nil {
+		key = done
+	} else {
+		return &globalDeadlineCount
+	}
+
+	now := time.Now().UnixNano()
+	if c, ok := deadlineCounters.Load(key); ok {
+		counter := c.(*deadlineCounter)
+		atomic.StoreInt64(&counter.lastUsed, now)
+		return &counter.count
+	}
+	c, loaded := deadlineCounters.LoadOrStore(key, &deadlineCounter{lastUsed: now, done: done})
+	if !loaded && atomic.AddUint64(&deadlineCountersCreated, 1)%deadlineCounterSweep == 0 {
+		sweepDeadlineCounters(now)
+	}
+	return &c.(*deadlineCounter).count
+}
+
+// sweepDeadlineCounters drops the counters of the contexts done or unused for
+// deadlineCounterIdle.
+func sweepDeadlineCounters(now int64) {
+	deadlineCounters.Range(func(key, value interface{}) bool {
+		counter := value.(*deadlineCounter)
+		select {
+		case <-counter.done:
+			deadlineCounters.Delete(key)
+			return true
+		default:
+		}
+		if now-atomic.LoadInt64(&counter.lastUsed) > int64(deadlineCounterIdle) {
+			deadlineCounters.Delete(key)
+		}
+		return true
+	})
+}
+
+// deadlineSamplerState is shared by a deadline sampler core and all cores
+// derived from it. Its count is shared by all loggers bound to the same
+// context, see deadlineCount.
+type deadlineSamplerState struct {
+	rate        uint64
+	window      time.Duration
+	deadline    time.Time
+	hasDeadline bool
+	count       *uint64
+}
+
+// sample reports whether an entry at lvl is logged: every entry at error
+// level or above or within the window before the deadline, one in rate
+// entries otherwise.
+func (s *deadlineSamplerState) sample(lvl zapcore.Level) bool {
+	if lvl >= zapcore.ErrorLevel {
+		return true
+	}
+	if s.hasDeadline && time.Until(s.deadline) <= s.window {
+		return true
+	}
+	return (atomic.AddUint64(s.count, 1)-1)%s.rate == 0
+}
+
+type deadlineSamplerCore struct {
+	zapcore.Core
+	state *deadlineSamplerState
+}
+
+func (c *deadlineSamplerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &deadlineSamplerCore{
+		Core:  c.Core.With(fields),
+		state: c.state,
+	}
+}
+
+func (c *deadlineSamplerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) || !c.state.sample(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,55 @@
+package otel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithDeadlineAwareSampling(t *testing.T) {
+	lg, logs := newObserved()
+	l := NewLogger(lg, WithDeadlineAwareSampling(3, time.Minute))
+
+	near, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	far, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	for i := 0; i < 9; i++ {
+		// bound anew for every entry, like G(ctx)
+		l.WithContext(near).Info("near")
+		l.WithContext(far).Info("far")
+		l.WithContext(far).Error("far error")
+	}
+
+	counts := map[string]int{}
+	for _, entry := range logs.All() {
+		counts[entry.Message]++
+	}
+	if counts["near"] != 9 || counts["far"] != 3 {
+		t.Errorf("got %d near and %d far entries, want 9 and 3", counts["near"], counts["far"])
+	}
+	if counts["far error"] != 9 {
+		t.Errorf("got %d far error entries, want all 9", counts["far error"])
+	}
+}
+
+func TestWithDeadlineAwareSamplingPerTrace(t *testing.T) {
+	tracer, _ := newTracer(t)
+	lg, logs := newObserved()
+	l := NewLogger(lg, WithDeadlineAwareSampling(4, time.Second), WithLogLevel(zapcore.FatalLevel))
+
+	ctx, span := tracer.Start(context.Background(), "request")
+	defer span.End()
+	for i := 0; i < 4; i++ {
+		// contexts of the same trace share the count
+		child, cancel := context.WithTimeout(ctx, time.Hour)
+		l.WithContext(child).Info("traced")
+		cancel()
+	}
+
+	if logs.Len() != 1 {
+		t.Errorf("got %d entries, want 1", logs.Len())
+	}
+}
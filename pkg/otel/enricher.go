@@ -0,0 +1,29 @@
+package otel
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Enricher maps a context bound to a logger and the span context it carries
+// to fields of the logger.
+type Enricher interface {
+	Enrich(ctx context.Context, spanContext trace.SpanContext) []zap.Field
+}
+
+// EnricherFunc adapts a function to an Enricher.
+type EnricherFunc func(ctx context.Context, spanContext trace.SpanContext) []zap.Field
+
+func (f EnricherFunc) Enrich(ctx context.Context, spanContext trace.SpanContext) []zap.Field {
+	return f(ctx, spanContext)
+}
+
+// WithEnricher adds the fields of e to the loggers bound to a context with
+// a valid span context. It can be given several times.
+func WithEnricher(e Enricher) Option {
+	return optionFunc(func(cfg *config) {
+		cfg.Enrichers = append(cfg.Enrichers[:len(cfg.Enrichers):len(cfg.Enrichers)], e)
+	})
+}
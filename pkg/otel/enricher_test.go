@@ -0,0 +1,37 @@
+package otel
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type tenantContextKey struct{}
+
+func TestWithEnricher(t *testing.T) {
+	tracer, _ := newTracer(t)
+	lg, logs := newObserved()
+	l := NewLogger(lg, WithEnricher(EnricherFunc(func(ctx context.Context, _ trace.SpanContext) []zap.Field {
+		if tenant, ok := ctx.Value(tenantContextKey{}).(string); ok {
+			return []zap.Field{zap.String("tenant", tenant)}
+		}
+		return nil
+	})))
+
+	ctx, span := tracer.Start(context.WithValue(context.Background(), tenantContextKey{}, "acme"), "op")
+	defer span.End()
+	l.WithContext(ctx).Info("logger")
+	l.Sugar().WithContext(ctx).Info("sugared")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	for _, entry := range entries {
+		if got := fieldValue(entry, "tenant"); got != "acme" {
+			t.Errorf("%s: got tenant %v, want acme", entry.Message, got)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package otel
 import (
 	"context"
 	"fmt"
+	"math"
 	"runtime"
 	"strconv"
 	"strings"
@@ -18,88 +19,185 @@ import (
 )
 
 const (
-	defaultTraceIdKey = "trace_id"
-	defaultSpanIdKey  = "span_id"
-	defaultSampledKey = "sampled"
+	defaultTraceIdKey      = "trace_id"
+	defaultSpanIdKey       = "span_id"
+	defaultSampledKey      = "sampled"
+	defaultSpanNameKey     = "span_name"
+	defaultParentSpanIdKey = "parent_span_id"
+	defaultSamplingKey     = "sampling_ratio"
+
+	shortIdLen = 8
+
+	sampledMarkerKey = "easylog.sampled"
+	noSpanErrorKey   = "easylog.no_span_error"
+
+	logArgErrorKey = "log_arg_error"
 )
 
 var (
 	logSeverityKey = attribute.Key("log.severity")
 	logMessageKey  = attribute.Key("log.message")
+
+	logStackFrameKey = attribute.Key("code.stacktrace.frame")
 )
 
-var _ izap.StdLogger = (*stdLogger)(nil)
+var (
+	_ izap.StdLogger        = (*stdLogger)(nil)
+	_ izap.DetachableLogger = (*stdLogger)(nil)
+)
 
 type stdLogger struct {
 	*zap.Logger
 	ctx context.Context
 
+	// noCallerLogger writes the entries whose caller was already resolved
+	// while recording the span event.
+	noCallerLogger *zap.Logger
+
 	LogLevel         zapcore.Level
 	ErrorStatusLevel zapcore.Level
 	CallerDepth      int8
 	CallerSkip       uint8
+	StructuredStack  bool
+}
+
+func newStdLogger(zLogger *zap.Logger, ctx context.Context, cfg config) *stdLogger {
+	zLogger = zLogger.WithOptions(zap.AddCallerSkip(1))
+	return &stdLogger{
+		Logger: zLogger,
+		ctx:    ctx,
+		// called from traceInfo, one frame deeper, for the stacktrace
+		noCallerLogger:   zLogger.WithOptions(zap.WithCaller(false), zap.AddCallerSkip(1)),
+		LogLevel:         cfg.LogLevel,
+		ErrorStatusLevel: cfg.ErrorStatusLevel,
+		CallerDepth:      cfg.CallerDepth,
+		CallerSkip:       cfg.CallerSkip,
+		StructuredStack:  cfg.StructuredStack,
+	}
 }
 
 func (l *stdLogger) Log(lvl zapcore.Level, msg string, fields ...zap.Field) {
-	l.traceInfo(lvl, msg)
+	if l.traceInfo(lvl, msg, fields) {
+		return
+	}
 	l.Logger.Log(lvl, msg, fields...)
 }
 
 func (l *stdLogger) Debug(msg string, fields ...zap.Field) {
-	l.traceInfo(zapcore.DebugLevel, msg)
+	if l.traceInfo(zapcore.DebugLevel, msg, fields) {
+		return
+	}
 	l.Logger.Debug(msg, fields...)
 }
 
 func (l *stdLogger) Info(msg string, fields ...zap.Field) {
-	l.traceInfo(zapcore.InfoLevel, msg)
+	if l.traceInfo(zapcore.InfoLevel, msg, fields) {
+		return
+	}
 	l.Logger.Info(msg, fields...)
 }
 
 func (l *stdLogger) Warn(msg string, fields ...zap.Field) {
-	l.traceInfo(zapcore.WarnLevel, msg)
+	if l.traceInfo(zapcore.WarnLevel, msg, fields) {
+		return
+	}
 	l.Logger.Warn(msg, fields...)
 }
 
 func (l *stdLogger) Error(msg string, fields ...zap.Field) {
-	l.traceInfo(zapcore.ErrorLevel, msg)
+	if l.traceInfo(zapcore.ErrorLevel, msg, fields) {
+		return
+	}
 	l.Logger.Error(msg, fields...)
 }
 
 func (l *stdLogger) Panic(msg string, fields ...zap.Field) {
-	l.traceInfo(zapcore.PanicLevel, msg)
+	if l.traceInfo(zapcore.PanicLevel, msg, fields) {
+		return
+	}
 	l.Logger.Panic(msg, fields...)
 }
 
 func (l *stdLogger) Fatal(msg string, fields ...zap.Field) {
-	l.traceInfo(zapcore.FatalLevel, msg)
+	if l.traceInfo(zapcore.FatalLevel, msg, fields) {
+		return
+	}
 	l.Logger.Fatal(msg, fields...)
 }
 
 func (l *stdLogger) DPanic(msg string, fields ...zap.Field) {
-	l.traceInfo(zapcore.DPanicLevel, msg)
+	if l.traceInfo(zapcore.DPanicLevel, msg, fields) {
+		return
+	}
 	l.Logger.DPanic(msg, fields...)
 }
 
-func (l *stdLogger) traceInfo(lvl zapcore.Level, msg string) {
+// Detach returns a logger that keeps the trace fields of l but no longer
+// records events to its span, for use after the span may have ended.
+func (l *stdLogger) Detach() izap.StdLogger {
+	detached := *l
+	detached.ctx = context.Background()
+	return &detached
+}
+
+// traceInfo records the entry to the span of l. When the caller was resolved
+// for the span event, it also writes the entry with that caller, sparing zap
+// a second stack walk, and reports true.
+func (l *stdLogger) traceInfo(lvl zapcore.Level, msg string, fields []zap.Field) bool {
 	span := trace.SpanFromContext(l.ctx)
 	if !span.IsRecording() {
-		return
+		return false
 	}
 
+	var caller zapcore.EntryCaller
 	if lvl >= l.LogLevel {
 		var attrs []attribute.KeyValue
 		attrs = append(attrs, logSeverityKey.String(lvl.String()))
 		attrs = append(attrs, logMessageKey.String(msg))
-		attrs = recordCaller(attrs, l.CallerDepth, int(l.CallerSkip+3))
+		attrs, caller = recordCaller(attrs, l.CallerDepth, int(l.CallerSkip+3), l.StructuredStack)
 		span.AddEvent("log", trace.WithAttributes(attrs...))
 	}
 
-	if lvl >= l.ErrorStatusLevel {
+	if lvl >= l.ErrorStatusLevel && !hasNoSpanError(fields) {
 		span.SetStatus(codes.Error, msg)
 	}
+
+	if !caller.Defined {
+		return false
+	}
+	if ce := l.noCallerLogger.Check(lvl, msg); ce != nil {
+		ce.Entry.Caller = caller
+		ce.Write(fields...)
+	}
+	return true
+}
+
+// NoSpanError returns a field marking an entry as an expected error which
+// must not set the status of the span to error. It is never encoded.
+func NoSpanError() zap.Field {
+	return zap.Field{Key: noSpanErrorKey, Type: zapcore.SkipType}
+}
+
+func hasNoSpanError(fields []zap.Field) bool {
+	for _, f := range fields {
+		if f.Type == zapcore.SkipType && f.Key == noSpanErrorKey {
+			return true
+		}
+	}
+	return false
 }
 
-func recordCaller(attrs []attribute.KeyValue, callerDepth int8, skip int) []attribute.KeyValue {
+func hasNoSpanErrorArg(keysAndValues []interface{}) bool {
+	for _, kv := range keysAndValues {
+		if f, ok := kv.(zap.Field); ok && f.Type == zapcore.SkipType && f.Key == noSpanErrorKey {
+			return true
+		}
+	}
+	return false
+}
+
+func recordCaller(attrs []attribute.KeyValue, callerDepth int8, skip int, structured bool) ([]attribute.KeyValue, zapcore.EntryCaller) {
+	var caller zapcore.EntryCaller
 	if callerDepth >= 0 {
 		var stack bool
 		var pc []uintptr
@@ -114,17 +212,19 @@ func recordCaller(attrs []attribute.KeyValue, callerDepth int8, skip int) []attr
 		frames := runtime.CallersFrames(pc)
 
 		var stackStr strings.Builder
+		var stackFrames []string
 		for i := 0; i < cc; i++ {
 			next, more := frames.Next()
-			if !more {
-				break
-			}
 			if i == 0 { //first frame
 				attrs = append(attrs, semconv.CodeFunctionKey.String(next.Function))
 				attrs = append(attrs, semconv.CodeFilepathKey.String(next.File))
 				attrs = append(attrs, semconv.CodeLineNumberKey.Int(next.Line))
+				caller = zapcore.NewEntryCaller(next.PC, next.File, next.Line, true)
+				caller.Function = next.Function
 			}
-			if stack {
+			if stack && structured {
+				stackFrames = append(stackFrames, next.Function+" "+next.File+":"+strconv.Itoa(next.Line))
+			} else if stack {
 				stackStr.WriteString(next.Function)
 				stackStr.WriteString(" ")
 				stackStr.WriteString(next.File)
@@ -132,48 +232,80 @@ func recordCaller(attrs []attribute.KeyValue, callerDepth int8, skip int) []attr
 				stackStr.WriteString(strconv.Itoa(next.Line))
 				stackStr.WriteString("\n")
 			}
+			if !more {
+				break
+			}
 		}
-		if stack {
+		if stack && structured {
+			attrs = append(attrs, logStackFrameKey.StringSlice(stackFrames))
+		} else if stack {
 			attrs = append(attrs, semconv.ExceptionStacktraceKey.String(stackStr.String()))
 		}
 	}
-	return attrs
+	return attrs, caller
 }
 
-func WithContext(ctx context.Context, zLogger *zap.Logger, opts ...Option) izap.StdLogger {
-	if ctx == nil {
-		return zLogger
+// spanAttributes is implemented by spans that expose their attributes,
+// such as the read-only spans of the otel sdk.
+type spanAttributes interface {
+	Attributes() []attribute.KeyValue
+}
+
+// spanName is implemented by spans that expose their name, such as the
+// read-only spans of the otel sdk.
+type spanName interface {
+	Name() string
+}
+
+// spanParent is implemented by spans that expose their parent, such as the
+// read-only spans of the otel sdk.
+type spanParent interface {
+	Parent() trace.SpanContext
+}
+
+// skipSpan reports whether the span in ctx carries the skip attribute key.
+func skipSpan(ctx context.Context, key string) bool {
+	if key == "" {
+		return false
+	}
+	span, ok := trace.SpanFromContext(ctx).(spanAttributes)
+	if !ok {
+		return false
+	}
+	for _, attr := range span.Attributes() {
+		if string(attr.Key) != key {
+			continue
+		}
+		if attr.Value.Type() == attribute.BOOL {
+			return attr.Value.AsBool()
+		}
+		return true
 	}
+	return false
+}
 
-	spanContext := trace.SpanContextFromContext(ctx)
-	if !spanContext.IsValid() { // must be !isRecording()
+func WithContext(ctx context.Context, zLogger *zap.Logger, opts ...Option) izap.StdLogger {
+	if ctx == nil {
 		return zLogger
 	}
 
 	cfg := applyConfig(opts...)
+	zLogger = withDeadlineSampling(zLogger, ctx, cfg)
 
-	var fields []zap.Field
-	if cfg.LogTraceId {
-		traceIdField := zap.String(defaultTraceIdKey, spanContext.TraceID().String())
-		fields = append(fields, traceIdField)
-	}
-	if cfg.LogSpanId {
-		spanIdField := zap.String(defaultSpanIdKey, spanContext.SpanID().String())
-		fields = append(fields, spanIdField)
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() { // remote span contexts are valid: fields, but no events
+		if fields := b3Fields(ctx, cfg); len(fields) > 0 {
+			return withTraceFields(zLogger, fields, cfg)
+		}
+		return zLogger
 	}
-	if cfg.LogSampled {
-		sampledField := zap.String(defaultSampledKey, spanContext.TraceFlags().String())
-		fields = append(fields, sampledField)
+	if skipSpan(ctx, cfg.SkipSpanAttribute) {
+		return zap.NewNop()
 	}
 
-	return &stdLogger{
-		Logger:           zLogger.WithOptions(zap.Fields(fields...), zap.AddCallerSkip(1)),
-		ctx:              ctx,
-		LogLevel:         cfg.LogLevel,
-		ErrorStatusLevel: cfg.ErrorStatusLevel,
-		CallerDepth:      cfg.CallerDepth,
-		CallerSkip:       cfg.CallerSkip,
-	}
+	fields := traceFields(ctx, spanContext, cfg)
+
+	return newStdLogger(withTraceFields(zLogger, fields, cfg), ctx, cfg)
 }
 
 var _ izap.StdSugaredLogger = (*stdSugaredLogger)(nil)
@@ -185,9 +317,10 @@ type stdSugaredLogger struct {
 	ErrorStatusLevel zapcore.Level
 	CallerDepth      int8
 	CallerSkip       uint8
+	StructuredStack  bool
 }
 
-func (s *stdSugaredLogger) sugaredTraceInfo(lvl zapcore.Level, msg string, ln bool, args []interface{}) {
+func (s *stdSugaredLogger) sugaredTraceInfo(lvl zapcore.Level, msg string, ln bool, args []interface{}, noSpanError bool) {
 	span := trace.SpanFromContext(s.ctx)
 	if !span.IsRecording() {
 		return
@@ -208,17 +341,78 @@ func (s *stdSugaredLogger) sugaredTraceInfo(lvl zapcore.Level, msg string, ln bo
 		var attrs []attribute.KeyValue
 		attrs = append(attrs, logSeverityKey.String(lvl.String()))
 		attrs = append(attrs, logMessageKey.String(msg))
-		attrs = recordCaller(attrs, s.CallerDepth, int(3+s.CallerSkip))
+		attrs, _ = recordCaller(attrs, s.CallerDepth, int(3+s.CallerSkip), s.StructuredStack)
 
 		//TODO record caller
 		span.AddEvent("log", trace.WithAttributes(attrs...))
 	}
 
-	if lvl >= s.ErrorStatusLevel {
+	if lvl >= s.ErrorStatusLevel && !noSpanError {
 		span.SetStatus(codes.Error, msg)
 	}
 }
 
+// checkKeysAndValues replaces a trailing key without a value by a
+// log_arg_error field naming it, instead of zap reporting it in a separate
+// entry.
+func checkKeysAndValues(keysAndValues []interface{}) []interface{} {
+	// walk the pairs as zap does, fields stand on their own
+	for i := 0; i < len(keysAndValues); {
+		if _, ok := keysAndValues[i].(zap.Field); ok {
+			i++
+			continue
+		}
+		if i == len(keysAndValues)-1 {
+			return append(keysAndValues[:i:i], zap.String(logArgErrorKey, fmt.Sprintf("key without a value: %v", keysAndValues[i])))
+		}
+		i += 2
+	}
+	return keysAndValues
+}
+
+// plainSugaredLogger is the sugared logger bound to a context without a
+// span: it only checks the keys and values of the w methods.
+type plainSugaredLogger struct {
+	*zap.SugaredLogger
+	// checked writes the entries of the w methods, skipping their frame
+	checked *zap.SugaredLogger
+}
+
+func newPlainSugaredLogger(s *zap.SugaredLogger) *plainSugaredLogger {
+	return &plainSugaredLogger{
+		SugaredLogger: s,
+		checked:       s.WithOptions(zap.AddCallerSkip(1)),
+	}
+}
+
+func (s *plainSugaredLogger) Debugw(msg string, keysAndValues ...interface{}) {
+	s.checked.Debugw(msg, checkKeysAndValues(keysAndValues)...)
+}
+
+func (s *plainSugaredLogger) Infow(msg string, keysAndValues ...interface{}) {
+	s.checked.Infow(msg, checkKeysAndValues(keysAndValues)...)
+}
+
+func (s *plainSugaredLogger) Warnw(msg string, keysAndValues ...interface{}) {
+	s.checked.Warnw(msg, checkKeysAndValues(keysAndValues)...)
+}
+
+func (s *plainSugaredLogger) Errorw(msg string, keysAndValues ...interface{}) {
+	s.checked.Errorw(msg, checkKeysAndValues(keysAndValues)...)
+}
+
+func (s *plainSugaredLogger) DPanicw(msg string, keysAndValues ...interface{}) {
+	s.checked.DPanicw(msg, checkKeysAndValues(keysAndValues)...)
+}
+
+func (s *plainSugaredLogger) Panicw(msg string, keysAndValues ...interface{}) {
+	s.checked.Panicw(msg, checkKeysAndValues(keysAndValues)...)
+}
+
+func (s *plainSugaredLogger) Fatalw(msg string, keysAndValues ...interface{}) {
+	s.checked.Fatalw(msg, checkKeysAndValues(keysAndValues)...)
+}
+
 // getMessage copy from zap.
 func getMessage(template string, fmtArgs []interface{}) string {
 	if len(fmtArgs) == 0 {
@@ -244,179 +438,294 @@ func getMessageln(fmtArgs []interface{}) string {
 }
 
 func (s *stdSugaredLogger) Debug(args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.DebugLevel, "", false, args)
+	s.sugaredTraceInfo(zapcore.DebugLevel, "", false, args, false)
 	s.SugaredLogger.Debug(args...)
 }
 
 func (s *stdSugaredLogger) Info(args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.InfoLevel, "", false, args)
+	s.sugaredTraceInfo(zapcore.InfoLevel, "", false, args, false)
 	s.SugaredLogger.Info(args...)
 }
 
 func (s *stdSugaredLogger) Warn(args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.WarnLevel, "", false, args)
+	s.sugaredTraceInfo(zapcore.WarnLevel, "", false, args, false)
 	s.SugaredLogger.Warn(args...)
 }
 
 func (s *stdSugaredLogger) Error(args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.ErrorLevel, "", false, args)
+	s.sugaredTraceInfo(zapcore.ErrorLevel, "", false, args, false)
 	s.SugaredLogger.Error(args...)
 }
 
 func (s *stdSugaredLogger) DPanic(args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.DPanicLevel, "", false, args)
+	s.sugaredTraceInfo(zapcore.DPanicLevel, "", false, args, false)
 	s.SugaredLogger.DPanic(args...)
 }
 
 func (s *stdSugaredLogger) Panic(args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.PanicLevel, "", false, args)
+	s.sugaredTraceInfo(zapcore.PanicLevel, "", false, args, false)
 	s.SugaredLogger.Panic(args...)
 }
 
 func (s *stdSugaredLogger) Fatal(args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.FatalLevel, "", false, args)
+	s.sugaredTraceInfo(zapcore.FatalLevel, "", false, args, false)
 	s.SugaredLogger.Fatal(args...)
 }
 
 func (s *stdSugaredLogger) Debugf(template string, args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.DebugLevel, template, false, args)
+	s.sugaredTraceInfo(zapcore.DebugLevel, template, false, args, false)
 	s.SugaredLogger.Debugf(template, args...)
 }
 
 func (s *stdSugaredLogger) Infof(template string, args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.InfoLevel, template, false, args)
+	s.sugaredTraceInfo(zapcore.InfoLevel, template, false, args, false)
 	s.SugaredLogger.Infof(template, args...)
 }
 
 func (s *stdSugaredLogger) Warnf(template string, args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.WarnLevel, template, false, args)
+	s.sugaredTraceInfo(zapcore.WarnLevel, template, false, args, false)
 	s.SugaredLogger.Warnf(template, args...)
 }
 
 func (s *stdSugaredLogger) Errorf(template string, args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.ErrorLevel, template, false, args)
+	s.sugaredTraceInfo(zapcore.ErrorLevel, template, false, args, false)
 	s.SugaredLogger.Errorf(template, args...)
 }
 
 func (s *stdSugaredLogger) DPanicf(template string, args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.DPanicLevel, template, false, args)
+	s.sugaredTraceInfo(zapcore.DPanicLevel, template, false, args, false)
 	s.SugaredLogger.DPanicf(template, args...)
 }
 
 func (s *stdSugaredLogger) Panicf(template string, args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.PanicLevel, template, false, args)
+	s.sugaredTraceInfo(zapcore.PanicLevel, template, false, args, false)
 	s.SugaredLogger.Panicf(template, args...)
 }
 
 func (s *stdSugaredLogger) Fatalf(template string, args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.FatalLevel, template, false, args)
+	s.sugaredTraceInfo(zapcore.FatalLevel, template, false, args, false)
 	s.SugaredLogger.Fatalf(template, args...)
 }
 
 func (s *stdSugaredLogger) Debugw(msg string, keysAndValues ...interface{}) {
-	s.sugaredTraceInfo(zapcore.DebugLevel, msg, false, nil)
+	keysAndValues = checkKeysAndValues(keysAndValues)
+	s.sugaredTraceInfo(zapcore.DebugLevel, msg, false, nil, hasNoSpanErrorArg(keysAndValues))
 	s.SugaredLogger.Debugw(msg, keysAndValues...)
 }
 
 func (s *stdSugaredLogger) Infow(msg string, keysAndValues ...interface{}) {
-	s.sugaredTraceInfo(zapcore.InfoLevel, msg, false, nil)
+	keysAndValues = checkKeysAndValues(keysAndValues)
+	s.sugaredTraceInfo(zapcore.InfoLevel, msg, false, nil, hasNoSpanErrorArg(keysAndValues))
 	s.SugaredLogger.Infow(msg, keysAndValues...)
 }
 
 func (s *stdSugaredLogger) Warnw(msg string, keysAndValues ...interface{}) {
-	s.sugaredTraceInfo(zapcore.WarnLevel, msg, false, nil)
+	keysAndValues = checkKeysAndValues(keysAndValues)
+	s.sugaredTraceInfo(zapcore.WarnLevel, msg, false, nil, hasNoSpanErrorArg(keysAndValues))
 	s.SugaredLogger.Warnw(msg, keysAndValues...)
 }
 
 func (s *stdSugaredLogger) Errorw(msg string, keysAndValues ...interface{}) {
-	s.sugaredTraceInfo(zapcore.ErrorLevel, msg, false, nil)
+	keysAndValues = checkKeysAndValues(keysAndValues)
+	s.sugaredTraceInfo(zapcore.ErrorLevel, msg, false, nil, hasNoSpanErrorArg(keysAndValues))
 	s.SugaredLogger.Errorw(msg, keysAndValues...)
 }
 
 func (s *stdSugaredLogger) DPanicw(msg string, keysAndValues ...interface{}) {
-	s.sugaredTraceInfo(zapcore.DPanicLevel, msg, false, nil)
+	keysAndValues = checkKeysAndValues(keysAndValues)
+	s.sugaredTraceInfo(zapcore.DPanicLevel, msg, false, nil, hasNoSpanErrorArg(keysAndValues))
 	s.SugaredLogger.DPanicw(msg, keysAndValues...)
 }
 
 func (s *stdSugaredLogger) Panicw(msg string, keysAndValues ...interface{}) {
-	s.sugaredTraceInfo(zapcore.PanicLevel, msg, false, nil)
+	keysAndValues = checkKeysAndValues(keysAndValues)
+	s.sugaredTraceInfo(zapcore.PanicLevel, msg, false, nil, hasNoSpanErrorArg(keysAndValues))
 	s.SugaredLogger.Panicw(msg, keysAndValues...)
 }
 
 func (s *stdSugaredLogger) Fatalw(msg string, keysAndValues ...interface{}) {
-	s.sugaredTraceInfo(zapcore.FatalLevel, msg, false, nil)
+	keysAndValues = checkKeysAndValues(keysAndValues)
+	s.sugaredTraceInfo(zapcore.FatalLevel, msg, false, nil, hasNoSpanErrorArg(keysAndValues))
 	s.SugaredLogger.Fatalw(msg, keysAndValues...)
 }
 
 func (s *stdSugaredLogger) Debugln(args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.DebugLevel, "", true, args)
+	s.sugaredTraceInfo(zapcore.DebugLevel, "", true, args, false)
 	s.SugaredLogger.Debugln(args...)
 }
 
 func (s *stdSugaredLogger) Infoln(args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.InfoLevel, "", true, args)
+	s.sugaredTraceInfo(zapcore.InfoLevel, "", true, args, false)
 	s.SugaredLogger.Infoln(args...)
 }
 
 func (s *stdSugaredLogger) Warnln(args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.WarnLevel, "", true, args)
+	s.sugaredTraceInfo(zapcore.WarnLevel, "", true, args, false)
 	s.SugaredLogger.Warnln(args...)
 }
 
 func (s *stdSugaredLogger) Errorln(args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.ErrorLevel, "", true, args)
+	s.sugaredTraceInfo(zapcore.ErrorLevel, "", true, args, false)
 	s.SugaredLogger.Errorln(args...)
 }
 
 func (s *stdSugaredLogger) DPanicln(args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.DPanicLevel, "", true, args)
+	s.sugaredTraceInfo(zapcore.DPanicLevel, "", true, args, false)
 	s.SugaredLogger.DPanicln(args...)
 }
 
 func (s *stdSugaredLogger) Panicln(args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.PanicLevel, "", true, args)
+	s.sugaredTraceInfo(zapcore.PanicLevel, "", true, args, false)
 	s.SugaredLogger.Panicln(args...)
 }
 
 func (s *stdSugaredLogger) Fatalln(args ...interface{}) {
-	s.sugaredTraceInfo(zapcore.FatalLevel, "", true, args)
+	s.sugaredTraceInfo(zapcore.FatalLevel, "", true, args, false)
 	s.SugaredLogger.Fatalln(args...)
 }
 
 func SugarWithContext(ctx context.Context, zsLogger *zap.SugaredLogger, opts ...Option) izap.StdSugaredLogger {
 	if ctx == nil {
-		return zsLogger
+		return newPlainSugaredLogger(zsLogger)
+	}
+
+	cfg := applyConfig(opts...)
+	if cfg.deadlineSampling() {
+		zsLogger = withDeadlineSampling(zsLogger.Desugar(), ctx, cfg).Sugar()
 	}
 
 	spanContext := trace.SpanContextFromContext(ctx)
-	if !spanContext.IsValid() { // must be !isRecording()
-		return zsLogger
+	if !spanContext.IsValid() { // remote span contexts are valid: fields, but no events
+		if fields := b3Fields(ctx, cfg); len(fields) > 0 {
+			return newPlainSugaredLogger(withTraceFields(zsLogger.Desugar(), fields, cfg).Sugar())
+		}
+		return newPlainSugaredLogger(zsLogger)
+	}
+	if skipSpan(ctx, cfg.SkipSpanAttribute) {
+		return zap.NewNop().Sugar()
 	}
 
-	cfg := applyConfig(opts...)
+	fields := traceFields(ctx, spanContext, cfg)
 
+	return &stdSugaredLogger{
+		SugaredLogger:    withTraceFields(zsLogger.Desugar(), fields, cfg).Sugar().WithOptions(zap.AddCallerSkip(1)),
+		ctx:              ctx,
+		LogLevel:         cfg.LogLevel,
+		ErrorStatusLevel: cfg.ErrorStatusLevel,
+		CallerDepth:      cfg.CallerDepth,
+		CallerSkip:       cfg.CallerSkip,
+		StructuredStack:  cfg.StructuredStack,
+	}
+}
+
+// traceFields returns the configured trace fields of spanContext, the span
+// context of ctx.
+func traceFields(ctx context.Context, spanContext trace.SpanContext, cfg config) []zap.Field {
 	var fields []zap.Field
 	if cfg.LogTraceId {
-		traceIdField := zap.String(defaultTraceIdKey, spanContext.TraceID().String())
+		traceIdField := zap.String(defaultTraceIdKey, shortId(spanContext.TraceID().String(), cfg))
 		fields = append(fields, traceIdField)
 	}
 	if cfg.LogSpanId {
-		spanIdField := zap.String(defaultSpanIdKey, spanContext.SpanID().String())
+		spanIdField := zap.String(defaultSpanIdKey, shortId(spanContext.SpanID().String(), cfg))
 		fields = append(fields, spanIdField)
 	}
 	if cfg.LogSampled {
 		sampledField := zap.String(defaultSampledKey, spanContext.TraceFlags().String())
 		fields = append(fields, sampledField)
 	}
+	if cfg.LogParentSpanId {
+		if span, ok := trace.SpanFromContext(ctx).(spanParent); ok && span.Parent().HasSpanID() {
+			fields = append(fields, zap.String(defaultParentSpanIdKey, shortId(span.Parent().SpanID().String(), cfg)))
+		}
+	}
+	if len(cfg.SpanAttributeFields) > 0 {
+		if span, ok := trace.SpanFromContext(ctx).(spanAttributes); ok {
+			fields = append(fields, spanAttributeFields(span.Attributes(), cfg.SpanAttributeFields)...)
+		}
+	}
+	if cfg.LogSpanName {
+		if span, ok := trace.SpanFromContext(ctx).(spanName); ok {
+			fields = append(fields, zap.String(defaultSpanNameKey, span.Name()))
+		}
+	}
+	if cfg.LogSamplingRatio {
+		fields = append(fields, zap.Float64(defaultSamplingKey, samplingRatio(spanContext, cfg.SamplingRatio)))
+	}
+	for _, e := range cfg.Enrichers {
+		fields = append(fields, e.Enrich(ctx, spanContext)...)
+	}
+	fields = append(fields, sampledMarker(spanContext.IsSampled()))
+	return fields
+}
 
-	return &stdSugaredLogger{
-		SugaredLogger:    zsLogger.WithOptions(zap.Fields(fields...), zap.AddCallerSkip(1)),
-		ctx:              ctx,
-		LogLevel:         cfg.LogLevel,
-		ErrorStatusLevel: cfg.ErrorStatusLevel,
-		CallerDepth:      cfg.CallerDepth,
-		CallerSkip:       cfg.CallerSkip,
+// samplingRatio returns the sampling probability recorded in the ot entry of
+// the trace state of spanContext, either as a p-value (probability 2^-p) or
+// as a rejection threshold th (probability 1 - th/2^56), falling back to
+// configured.
+func samplingRatio(spanContext trace.SpanContext, configured float64) float64 {
+	for _, kv := range strings.Split(spanContext.TraceState().Get("ot"), ";") {
+		k, v, _ := strings.Cut(kv, ":")
+		switch k {
+		case "p":
+			if p, err := strconv.Atoi(v); err == nil && p >= 0 && p <= 63 {
+				return math.Ldexp(1, -p)
+			}
+		case "th":
+			if len(v) > 0 && len(v) <= 14 {
+				if th, err := strconv.ParseUint(v+strings.Repeat("0", 14-len(v)), 16, 64); err == nil {
+					return 1 - math.Ldexp(float64(th), -56)
+				}
+			}
+		}
+	}
+	return configured
+}
+
+// sampledMarker returns a field that is never encoded but carries the
+// sampling decision of the span to the cores of the logger.
+func sampledMarker(sampled bool) zap.Field {
+	return zap.Field{Key: sampledMarkerKey, Type: zapcore.SkipType, Integer: boolToInt64(sampled)}
+}
+
+func boolToInt64(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// SampledFromFields returns the sampling decision attached to fields by a
+// context-carrying logger. ok is false if fields carry no decision.
+func SampledFromFields(fields []zapcore.Field) (sampled, ok bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if f := fields[i]; f.Type == zapcore.SkipType && f.Key == sampledMarkerKey {
+			return f.Integer == 1, true
+		}
+	}
+	return false, false
+}
+
+// spanAttributeFields returns the attributes named by keys as fields.
+func spanAttributeFields(attrs []attribute.KeyValue, keys []string) []zap.Field {
+	var fields []zap.Field
+	for _, attr := range attrs {
+		for _, key := range keys {
+			if string(attr.Key) == key {
+				fields = append(fields, zap.Any(key, attr.Value.AsInterface()))
+				break
+			}
+		}
+	}
+	return fields
+}
+
+// shortId truncates id to its last shortIdLen chars when short ids are enabled.
+func shortId(id string, cfg config) string {
+	if cfg.ShortIds && len(id) > shortIdLen {
+		return id[len(id)-shortIdLen:]
 	}
+	return id
 }
 
 func applyConfig(opts ...Option) config {
@@ -425,6 +734,8 @@ func applyConfig(opts ...Option) config {
 		LogLevel:         zapcore.ErrorLevel,
 		ErrorStatusLevel: zapcore.ErrorLevel,
 		CallerDepth:      8,
+
+		TraceFieldsMinLevel: zapcore.DebugLevel,
 	}
 	for _, opt := range opts {
 		opt.apply(&cfg)
@@ -432,7 +743,10 @@ func applyConfig(opts ...Option) config {
 	return cfg
 }
 
-var _ izap.Logger = (*logger)(nil)
+var (
+	_ izap.Logger      = (*logger)(nil)
+	_ izap.NamedLogger = (*logger)(nil)
+)
 
 type logger struct {
 	*zap.Logger
@@ -448,31 +762,30 @@ func NewLogger(log *zap.Logger, opts ...Option) izap.Logger {
 }
 
 func (l *logger) WithContext(ctx context.Context) izap.StdLogger {
+	zLogger := withDeadlineSampling(l.Logger, ctx, l.cfg)
+
 	spanContext := trace.SpanContextFromContext(ctx)
-	if !spanContext.IsValid() { // must be !isRecording()
+	if !spanContext.IsValid() { // remote span contexts are valid: fields, but no events
+		if fields := b3Fields(ctx, l.cfg); len(fields) > 0 {
+			return withTraceFields(zLogger, fields, l.cfg)
+		}
+		if l.cfg.deadlineSampling() {
+			return zLogger
+		}
 		return l
 	}
-	var fields []zap.Field
-
-	if l.cfg.LogTraceId {
-		traceIdField := zap.String(defaultTraceIdKey, spanContext.TraceID().String())
-		fields = append(fields, traceIdField)
-	}
-	if l.cfg.LogSpanId {
-		spanIdField := zap.String(defaultSpanIdKey, spanContext.SpanID().String())
-		fields = append(fields, spanIdField)
+	if skipSpan(ctx, l.cfg.SkipSpanAttribute) {
+		return zap.NewNop()
 	}
-	if l.cfg.LogSampled {
-		sampledField := zap.String(defaultSampledKey, spanContext.TraceFlags().String())
-		fields = append(fields, sampledField)
-	}
-	return &stdLogger{
-		Logger:           l.Logger.WithOptions(zap.Fields(fields...), zap.AddCallerSkip(1)),
-		ctx:              ctx,
-		LogLevel:         l.cfg.LogLevel,
-		ErrorStatusLevel: l.cfg.ErrorStatusLevel,
-		CallerDepth:      l.cfg.CallerDepth,
-		CallerSkip:       l.cfg.CallerSkip,
+	fields := traceFields(ctx, spanContext, l.cfg)
+	return newStdLogger(withTraceFields(zLogger, fields, l.cfg), ctx, l.cfg)
+}
+
+func (l *logger) Named(name string) izap.Logger {
+	newL := l.Logger.Named(name)
+	return &logger{
+		Logger: newL,
+		cfg:    l.cfg,
 	}
 }
 
@@ -508,7 +821,10 @@ func NewSugaredLogger(log *zap.SugaredLogger, opts ...Option) izap.SugaredLogger
 	}
 }
 
-var _ izap.SugaredLogger = (*sugaredLogger)(nil)
+var (
+	_ izap.SugaredLogger      = (*sugaredLogger)(nil)
+	_ izap.NamedSugaredLogger = (*sugaredLogger)(nil)
+)
 
 type sugaredLogger struct {
 	*zap.SugaredLogger
@@ -516,35 +832,40 @@ type sugaredLogger struct {
 }
 
 func (o *sugaredLogger) WithContext(ctx context.Context) izap.StdSugaredLogger {
+	zLogger := withDeadlineSampling(o.SugaredLogger.Desugar(), ctx, o.cfg)
+
 	spanContext := trace.SpanContextFromContext(ctx)
-	if !spanContext.IsValid() { // must be !isRecording()
-		return o
-	}
-	var fields []zap.Field
-	if o.cfg.LogTraceId {
-		traceIdField := zap.String(defaultTraceIdKey, spanContext.TraceID().String())
-		fields = append(fields, traceIdField)
-	}
-	if o.cfg.LogSpanId {
-		spanIdField := zap.String(defaultSpanIdKey, spanContext.SpanID().String())
-		fields = append(fields, spanIdField)
+	if !spanContext.IsValid() { // remote span contexts are valid: fields, but no events
+		if fields := b3Fields(ctx, o.cfg); len(fields) > 0 {
+			return newPlainSugaredLogger(withTraceFields(zLogger, fields, o.cfg).Sugar())
+		}
+		return newPlainSugaredLogger(zLogger.Sugar())
 	}
-	if o.cfg.LogSampled {
-		sampledField := zap.String(defaultSampledKey, spanContext.TraceFlags().String())
-		fields = append(fields, sampledField)
+	if skipSpan(ctx, o.cfg.SkipSpanAttribute) {
+		return zap.NewNop().Sugar()
 	}
+	fields := traceFields(ctx, spanContext, o.cfg)
 	return &stdSugaredLogger{
-		SugaredLogger:    o.SugaredLogger.WithOptions(zap.Fields(fields...), zap.AddCallerSkip(1)),
+		SugaredLogger:    withTraceFields(zLogger, fields, o.cfg).Sugar().WithOptions(zap.AddCallerSkip(1)),
 		ctx:              ctx,
 		LogLevel:         o.cfg.LogLevel,
 		ErrorStatusLevel: o.cfg.ErrorStatusLevel,
 		CallerDepth:      o.cfg.CallerDepth,
 		CallerSkip:       o.cfg.CallerSkip,
+		StructuredStack:  o.cfg.StructuredStack,
+	}
+}
+
+func (o *sugaredLogger) Named(name string) izap.SugaredLogger {
+	sl := o.SugaredLogger.Named(name)
+	return &sugaredLogger{
+		SugaredLogger: sl,
+		cfg:           o.cfg,
 	}
 }
 
 func (o *sugaredLogger) With(args ...interface{}) izap.SugaredLogger {
-	sl := o.SugaredLogger.With(args)
+	sl := o.SugaredLogger.With(args...)
 	return &sugaredLogger{
 		SugaredLogger: sl,
 		cfg:           o.cfg,
@@ -0,0 +1,409 @@
+package otel
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/logerror/easylog/pkg/izap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// newTracer returns a tracer whose spans are recorded by the returned
+// recorder once ended.
+func newTracer(t testing.TB) (trace.Tracer, *tracetest.SpanRecorder) {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	t.Cleanup(func() {
+		_ = provider.Shutdown(context.Background())
+	})
+	return provider.Tracer("otel"), recorder
+}
+
+// newObserved returns a logger recording its entries at any level.
+func newObserved() (*zap.Logger, *observer.ObservedLogs) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	return zap.New(core, zap.AddCaller()), logs
+}
+
+// fieldValue returns the value of the field key of entry, nil if absent.
+func fieldValue(entry observer.LoggedEntry, key string) interface{} {
+	return entry.ContextMap()[key]
+}
+
+func TestWithSkipSpanAttribute(t *testing.T) {
+	tracer, recorder := newTracer(t)
+	lg, logs := newObserved()
+	l := NewLogger(lg, WithSkipSpanAttribute("health"), WithLogLevel(zapcore.InfoLevel))
+
+	ctx, span := tracer.Start(context.Background(), "probe", trace.WithAttributes(attribute.Bool("health", true)))
+	l.WithContext(ctx).Info("skipped")
+	l.Sugar().WithContext(ctx).Infow("skipped too")
+	span.End()
+
+	ctx, span = tracer.Start(context.Background(), "request", trace.WithAttributes(attribute.Bool("health", false)))
+	l.WithContext(ctx).Info("logged")
+	span.End()
+
+	if logs.Len() != 1 || logs.All()[0].Message != "logged" {
+		t.Errorf("got entries %v, want only logged", logs.All())
+	}
+	spans := recorder.Ended()
+	if n := len(spans[0].Events()); n != 0 {
+		t.Errorf("skipped span got %d events, want 0", n)
+	}
+	if n := len(spans[1].Events()); n != 1 {
+		t.Errorf("logged span got %d events, want 1", n)
+	}
+}
+
+func TestWithShortIDs(t *testing.T) {
+	tracer, _ := newTracer(t)
+	lg, logs := newObserved()
+	l := NewLogger(lg, WithShortIDs(true), WithLogSpanId(true))
+
+	ctx, span := tracer.Start(context.Background(), "op")
+	defer span.End()
+	l.WithContext(ctx).Info("short")
+
+	traceId, spanId := span.SpanContext().TraceID().String(), span.SpanContext().SpanID().String()
+	entry := logs.All()[0]
+	if got := fieldValue(entry, defaultTraceIdKey); got != traceId[len(traceId)-8:] {
+		t.Errorf("got trace_id %v, want suffix of %s", got, traceId)
+	}
+	if got := fieldValue(entry, defaultSpanIdKey); got != spanId[len(spanId)-8:] {
+		t.Errorf("got span_id %v, want suffix of %s", got, spanId)
+	}
+}
+
+func TestWithSpanNameField(t *testing.T) {
+	tracer, _ := newTracer(t)
+	lg, logs := newObserved()
+	l := NewLogger(lg, WithSpanNameField(true))
+
+	ctx, span := tracer.Start(context.Background(), "GET /users")
+	defer span.End()
+	l.WithContext(ctx).Info("named")
+	l.Sugar().WithContext(ctx).Infow("named too")
+
+	for _, entry := range logs.All() {
+		if got := fieldValue(entry, defaultSpanNameKey); got != "GET /users" {
+			t.Errorf("%s: got span_name %v, want GET /users", entry.Message, got)
+		}
+	}
+}
+
+func TestWithStructuredStack(t *testing.T) {
+	tracer, recorder := newTracer(t)
+	lg, _ := newObserved()
+	l := NewLogger(lg, WithStructuredStack(true), WithLogLevel(zapcore.InfoLevel))
+
+	ctx, span := tracer.Start(context.Background(), "op")
+	l.WithContext(ctx).Info("framed")
+	span.End()
+
+	events := recorder.Ended()[0].Events()
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	var frames []string
+	for _, attr := range events[0].Attributes {
+		if attr.Key == semconv.ExceptionStacktraceKey {
+			t.Errorf("got stacktrace string %q, want frames", attr.Value.AsString())
+		}
+		if attr.Key == logStackFrameKey {
+			frames = attr.Value.AsStringSlice()
+		}
+	}
+	if len(frames) == 0 || !strings.Contains(frames[0], "TestWithStructuredStack") {
+		t.Errorf("got frames %v, want the test function first", frames)
+	}
+}
+
+func TestDetach(t *testing.T) {
+	tracer, recorder := newTracer(t)
+	lg, logs := newObserved()
+	l := NewLogger(lg, WithLogLevel(zapcore.InfoLevel))
+
+	ctx, span := tracer.Start(context.Background(), "request")
+	detached := izap.Detach(l.WithContext(ctx))
+	span.End()
+	detached.Info("background")
+
+	if n := len(recorder.Ended()[0].Events()); n != 0 {
+		t.Errorf("got %d span events, want 0", n)
+	}
+	traceId := span.SpanContext().TraceID().String()
+	if got := fieldValue(logs.All()[0], defaultTraceIdKey); got != traceId {
+		t.Errorf("got trace_id %v, want %s", got, traceId)
+	}
+}
+
+func TestDetachWithoutSpan(t *testing.T) {
+	lg, _ := newObserved()
+	l := NewLogger(lg).WithContext(context.Background())
+
+	if izap.Detach(l) != l {
+		t.Error("got a new logger, want the logger without span itself")
+	}
+}
+
+func TestWithParentSpanIdField(t *testing.T) {
+	tracer, _ := newTracer(t)
+	lg, logs := newObserved()
+	l := NewLogger(lg, WithParentSpanIdField(true))
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	defer parent.End()
+	childCtx, child := tracer.Start(ctx, "child")
+	defer child.End()
+	l.WithContext(ctx).Info("root")
+	l.WithContext(childCtx).Info("child")
+
+	entries := logs.All()
+	if v := fieldValue(entries[0], defaultParentSpanIdKey); v != nil {
+		t.Errorf("got parent_span_id %v for the root span, want none", v)
+	}
+	if v := fieldValue(entries[1], defaultParentSpanIdKey); v != parent.SpanContext().SpanID().String() {
+		t.Errorf("got parent_span_id %v, want %s", v, parent.SpanContext().SpanID())
+	}
+}
+
+func TestWithSpanAttributesAsFields(t *testing.T) {
+	tracer, _ := newTracer(t)
+	lg, logs := newObserved()
+	l := NewLogger(lg, WithSpanAttributesAsFields("http.method", "http.status_code"))
+
+	ctx, span := tracer.Start(context.Background(), "request", trace.WithAttributes(
+		attribute.String("http.method", "GET"),
+		attribute.Int("http.status_code", 200),
+		attribute.String("http.url", "/users"),
+	))
+	defer span.End()
+	l.WithContext(ctx).Info("served")
+
+	entry := logs.All()[0]
+	if v := fieldValue(entry, "http.method"); v != "GET" {
+		t.Errorf("got http.method %v, want GET", v)
+	}
+	if v := fieldValue(entry, "http.status_code"); v != int64(200) {
+		t.Errorf("got http.status_code %v, want 200", v)
+	}
+	if v := fieldValue(entry, "http.url"); v != nil {
+		t.Errorf("got http.url %v, want none", v)
+	}
+}
+
+func TestNoSpanError(t *testing.T) {
+	tracer, recorder := newTracer(t)
+	lg, _ := newObserved()
+	l := NewLogger(lg)
+
+	ctx, span := tracer.Start(context.Background(), "expected")
+	l.WithContext(ctx).Error("not found", NoSpanError())
+	l.Sugar().WithContext(ctx).Errorw("not found", NoSpanError())
+	span.End()
+
+	ctx, span = tracer.Start(context.Background(), "unexpected")
+	l.WithContext(ctx).Error("failed")
+	span.End()
+
+	spans := recorder.Ended()
+	if code := spans[0].Status().Code; code != codes.Unset {
+		t.Errorf("got status %v with the marker, want unset", code)
+	}
+	if code := spans[1].Status().Code; code != codes.Error {
+		t.Errorf("got status %v without the marker, want error", code)
+	}
+}
+
+func TestTraceInfoSharesCaller(t *testing.T) {
+	tracer, recorder := newTracer(t)
+	lg, logs := newObserved()
+	l := NewLogger(lg, WithLogLevel(zapcore.InfoLevel))
+
+	ctx, span := tracer.Start(context.Background(), "op")
+	l.WithContext(ctx).Info("shared")
+	span.End()
+
+	var line int64
+	for _, attr := range recorder.Ended()[0].Events()[0].Attributes {
+		if attr.Key == semconv.CodeLineNumberKey {
+			line = attr.Value.AsInt64()
+		}
+	}
+	caller := logs.All()[0].Caller
+	if !strings.HasSuffix(caller.File, "trace_test.go") || int64(caller.Line) != line {
+		t.Errorf("got entry caller %s, want trace_test.go:%d as in the span event", caller, line)
+	}
+}
+
+// BenchmarkTraceInfo compares logging with a recording span, which walks the
+// stack once for both the span event and the entry caller, to walking it
+// separately for each.
+func BenchmarkTraceInfo(b *testing.B) {
+	tracer, _ := newTracer(b)
+	lg := zap.New(zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(io.Discard),
+		zapcore.DebugLevel,
+	), zap.AddCaller())
+	ctx, span := tracer.Start(context.Background(), "op")
+	defer span.End()
+
+	b.Run("shared walk", func(b *testing.B) {
+		l := NewLogger(lg, WithLogLevel(zapcore.InfoLevel)).WithContext(ctx)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			l.Info("hello")
+		}
+	})
+	b.Run("separate walks", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			attrs, _ := recordCaller(nil, 8, 1, false)
+			span.AddEvent("log", trace.WithAttributes(attrs...))
+			lg.Info("hello")
+		}
+	})
+}
+
+func TestWithTraceFieldsMinLevel(t *testing.T) {
+	tracer, _ := newTracer(t)
+	lg, logs := newObserved()
+	l := NewLogger(lg, WithTraceFieldsMinLevel("warn"))
+
+	ctx, span := tracer.Start(context.Background(), "op")
+	defer span.End()
+	l.WithContext(ctx).Debug("debug")
+	l.Sugar().WithContext(ctx).Infow("info")
+	l.WithContext(ctx).Error("error")
+
+	entries := logs.All()
+	traceId := span.SpanContext().TraceID().String()
+	for i, want := range []interface{}{nil, nil, traceId} {
+		if got := fieldValue(entries[i], defaultTraceIdKey); got != want {
+			t.Errorf("%s: got trace_id %v, want %v", entries[i].Message, got, want)
+		}
+	}
+}
+
+func TestRemoteSpanContext(t *testing.T) {
+	tracer, recorder := newTracer(t)
+	lg, logs := newObserved()
+	l := NewLogger(lg, WithLogLevel(zapcore.InfoLevel))
+
+	// a local span that must not receive the events of the remote one
+	ctx, local := tracer.Start(context.Background(), "local")
+	remote := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0xab},
+		SpanID:     trace.SpanID{0xcd},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	ctx = trace.ContextWithRemoteSpanContext(ctx, remote)
+	l.WithContext(ctx).Error("logger")
+	l.Sugar().WithContext(ctx).Errorw("sugared")
+	WithContext(ctx, lg, WithLogLevel(zapcore.InfoLevel)).Error("function")
+	SugarWithContext(ctx, lg.Sugar(), WithLogLevel(zapcore.InfoLevel)).Errorw("sugared function")
+	local.End()
+
+	if logs.Len() != 4 {
+		t.Fatalf("got %d entries, want 4", logs.Len())
+	}
+	for _, entry := range logs.All() {
+		if got := fieldValue(entry, defaultTraceIdKey); got != remote.TraceID().String() {
+			t.Errorf("%s: got trace_id %v, want %s", entry.Message, got, remote.TraceID())
+		}
+	}
+	if n := len(recorder.Ended()[0].Events()); n != 0 {
+		t.Errorf("got %d events on the local span, want 0", n)
+	}
+	if code := recorder.Ended()[0].Status().Code; code != codes.Unset {
+		t.Errorf("got status %v on the local span, want unset", code)
+	}
+}
+
+func TestKeyWithoutValue(t *testing.T) {
+	tracer, _ := newTracer(t)
+	lg, logs := newObserved()
+	l := NewSugaredLogger(lg.Sugar())
+
+	spanCtx, span := tracer.Start(context.Background(), "op")
+	defer span.End()
+	l.WithContext(spanCtx).Infow("span", "lonelykey")
+	l.WithContext(context.Background()).Infow("no span", "lonelykey")
+	SugarWithContext(nil, lg.Sugar()).Infow("nil", "lonelykey")
+
+	entries := logs.All()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	for _, entry := range entries {
+		if got := fieldValue(entry, logArgErrorKey); got != "key without a value: lonelykey" {
+			t.Errorf("%s: got log_arg_error %v, want the lonely key", entry.Message, got)
+		}
+		if !strings.Contains(entry.Caller.File, "trace_test.go") {
+			t.Errorf("%s: got caller %s, want trace_test.go", entry.Message, entry.Caller)
+		}
+	}
+}
+
+func TestWithSamplingProbabilityField(t *testing.T) {
+	tracer, _ := newTracer(t)
+	lg, logs := newObserved()
+	l := NewLogger(lg, WithSamplingProbabilityField(0.1))
+
+	ctx, span := tracer.Start(context.Background(), "op")
+	defer span.End()
+	l.WithContext(ctx).Info("configured")
+	for _, ot := range []string{"p:2", "th:8"} {
+		state, err := trace.ParseTraceState("ot=" + ot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sc := span.SpanContext().WithTraceState(state)
+		l.WithContext(trace.ContextWithSpanContext(ctx, sc)).Info(ot)
+	}
+
+	entries := logs.All()
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	for i, want := range []float64{0.1, 0.25, 0.5} {
+		if got := fieldValue(entries[i], defaultSamplingKey); got != want {
+			t.Errorf("%s: got sampling_ratio %v, want %v", entries[i].Message, got, want)
+		}
+	}
+}
+
+func TestSugaredLoggerWith(t *testing.T) {
+	tracer, _ := newTracer(t)
+	lg, logs := newObserved()
+	l := NewSugaredLogger(lg.Sugar()).With("k", "v")
+
+	ctx, span := tracer.Start(context.Background(), "op")
+	defer span.End()
+	l.Info("plain")
+	l.WithContext(ctx).Info("traced")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	for _, entry := range entries {
+		if got := fieldValue(entry, "k"); got != "v" {
+			t.Errorf("%s: got k=%v, want v", entry.Message, got)
+		}
+	}
+}
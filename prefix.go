@@ -0,0 +1,41 @@
+package easylog
+
+import (
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+var prefixPool = buffer.NewPool()
+
+// prefixEncoder prepends a fixed prefix to every encoded line.
+type prefixEncoder struct {
+	zapcore.Encoder
+	prefix string
+}
+
+func newPrefixEncoder(enc zapcore.Encoder, prefix string) zapcore.Encoder {
+	return &prefixEncoder{
+		Encoder: enc,
+		prefix:  prefix,
+	}
+}
+
+func (e *prefixEncoder) Clone() zapcore.Encoder {
+	return &prefixEncoder{
+		Encoder: e.Encoder.Clone(),
+		prefix:  e.prefix,
+	}
+}
+
+func (e *prefixEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer buf.Free()
+
+	out := prefixPool.Get()
+	out.AppendString(e.prefix)
+	_, _ = out.Write(buf.Bytes())
+	return out, nil
+}
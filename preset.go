@@ -0,0 +1,78 @@
+package easylog
+
+import (
+	"os"
+	"time"
+
+	"github.com/logerror/easylog/pkg/option"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// presetOption applies a bundle of options at once.
+type presetOption []option.Option
+
+func (p presetOption) Apply() {
+	for _, o := range p {
+		o.Apply()
+	}
+}
+
+// bunyanLevels maps zap levels to the numeric levels of Bunyan.
+var bunyanLevels = map[zapcore.Level]int64{
+	zapcore.DebugLevel:  20,
+	zapcore.InfoLevel:   30,
+	zapcore.WarnLevel:   40,
+	zapcore.ErrorLevel:  50,
+	zapcore.DPanicLevel: 50,
+	zapcore.PanicLevel:  60,
+	zapcore.FatalLevel:  60,
+}
+
+// BunyanPreset returns an option producing Bunyan compatible JSON lines:
+// numeric levels, ISO 8601 UTC times and the v, name, hostname and pid
+// fields, with name set to the given application name.
+func BunyanPreset(name string) option.Option {
+	hostname, _ := os.Hostname()
+	return presetOption{
+		option.WithEncoderConfig(func(cfg *zapcore.EncoderConfig) {
+			cfg.TimeKey = "time"
+			cfg.LevelKey = "level"
+			cfg.MessageKey = "msg"
+			// "name" is the application name in Bunyan
+			cfg.NameKey = "logger"
+			cfg.EncodeLevel = func(lvl zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+				enc.AppendInt64(bunyanLevels[lvl])
+			}
+			cfg.EncodeTime = func(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
+				encodeTimeLayout(t.UTC(), "2006-01-02T15:04:05.000Z07:00", enc)
+			}
+		}),
+		option.WithAdditionalFields(
+			zap.Int("v", 0),
+			zap.String("name", name),
+			zap.String("hostname", hostname),
+			zap.Int("pid", os.Getpid()),
+		),
+	}
+}
+
+// k8sEnvFields maps the environment variables usually set from the
+// Kubernetes downward API to the fields carrying them.
+var k8sEnvFields = []struct{ env, key string }{
+	{"POD_NAME", "pod"},
+	{"POD_NAMESPACE", "namespace"},
+	{"NODE_NAME", "node"},
+}
+
+// K8sPreset returns an option adding the pod, namespace and node fields to
+// every entry, read from the POD_NAME, POD_NAMESPACE and NODE_NAME
+// environment variables when the logger is built. Unset variables are
+// skipped.
+func K8sPreset() option.Option {
+	var preset presetOption
+	for _, f := range k8sEnvFields {
+		preset = append(preset, option.WithEnvField(f.key, f.env))
+	}
+	return preset
+}
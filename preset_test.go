@@ -0,0 +1,65 @@
+package easylog
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/logerror/easylog/pkg/option"
+	"go.uber.org/zap"
+)
+
+func TestBunyanPreset(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithAdditionalFields(zap.String("region", "eu")), BunyanPreset("billing"))
+
+	l.Info("hello")
+	l.Error("failed")
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 2 {
+		t.Fatalf("got %d lines, want 2", len(entries))
+	}
+	hostname, _ := os.Hostname()
+	for _, entry := range entries {
+		if entry["v"] != 0.0 || entry["name"] != "billing" || entry["hostname"] != hostname || entry["pid"] != float64(os.Getpid()) {
+			t.Errorf("got %v, want the Bunyan fields", entry)
+		}
+		if _, err := time.Parse(time.RFC3339, entry["time"].(string)); err != nil {
+			t.Errorf("got time %v, want RFC 3339: %v", entry["time"], err)
+		}
+		// fields of other options are kept
+		if entry["region"] != "eu" {
+			t.Errorf("got region %v, want eu", entry["region"])
+		}
+	}
+	if entries[0]["level"] != 30.0 || entries[1]["level"] != 50.0 {
+		t.Errorf("got levels %v and %v, want 30 and 50", entries[0]["level"], entries[1]["level"])
+	}
+	if entries[0]["msg"] != "hello" {
+		t.Errorf("got msg %v, want hello", entries[0]["msg"])
+	}
+}
+
+func TestK8sPreset(t *testing.T) {
+	preset := K8sPreset()
+	// read when the logger is built, not when the preset is created
+	t.Setenv("POD_NAME", "api-7d9f")
+	t.Setenv("POD_NAMESPACE", "prod")
+	t.Setenv("NODE_NAME", "")
+	os.Unsetenv("NODE_NAME")
+
+	l, buf := newBufferLogger(t, preset, BunyanPreset("api"))
+	l.Info("hello")
+
+	entry := decodeLine(t, buf.String())
+	if entry["pod"] != "api-7d9f" || entry["namespace"] != "prod" {
+		t.Errorf("got pod %v and namespace %v, want api-7d9f and prod", entry["pod"], entry["namespace"])
+	}
+	if node, ok := entry["node"]; ok {
+		t.Errorf("got node %v, want none", node)
+	}
+	// fields of other presets are kept
+	if entry["name"] != "api" {
+		t.Errorf("got name %v, want api", entry["name"])
+	}
+}
@@ -0,0 +1,22 @@
+package easylog
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// InjectTraceHeaders writes the trace context of ctx into carrier, e.g. the
+// headers of a message sent to a queue, using the global propagator set with
+// otel.SetTextMapPropagator. Nothing is written if none is set.
+func InjectTraceHeaders(ctx context.Context, carrier propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
+
+// ExtractTraceContext returns a context holding the trace context read from
+// carrier by the global propagator, so that the logs of G and GS of the
+// consumer of a message carry the trace id of its producer.
+func ExtractTraceContext(carrier propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+}
@@ -0,0 +1,27 @@
+package easylog
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestTraceHeadersRoundTrip(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+	tracer, _ := newTracer(t)
+
+	ctx, span := tracer.Start(context.Background(), "produce")
+	headers := propagation.MapCarrier{}
+	InjectTraceHeaders(ctx, headers)
+	span.End()
+	G(ExtractTraceContext(headers)).Info("consumed")
+
+	if entry := decodeLine(t, buf.String()); entry["trace_id"] != span.SpanContext().TraceID().String() {
+		t.Errorf("got trace_id %v, want %s", entry["trace_id"], span.SpanContext().TraceID())
+	}
+}
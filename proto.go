@@ -0,0 +1,24 @@
+package easylog
+
+import (
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// protoMessage encodes a protobuf message through its canonical JSON
+// mapping instead of reflecting over the generated struct.
+type protoMessage struct {
+	proto.Message
+}
+
+func (m protoMessage) MarshalJSON() ([]byte, error) {
+	return protojson.MarshalOptions{}.Marshal(m.Message)
+}
+
+// Proto constructs a field that renders m compactly as its protojson
+// representation. Unknown fields are never rendered. The message is only
+// marshaled if the entry is actually written.
+func Proto(key string, m proto.Message) Field {
+	return Field{Key: key, Type: zapcore.ReflectType, Interface: protoMessage{m}}
+}
@@ -0,0 +1,24 @@
+package easylog
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestProto(t *testing.T) {
+	l, buf := newBufferLogger(t)
+	m, err := structpb.NewStruct(map[string]interface{}{"name": "order", "count": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Info("message", Proto("req", m))
+
+	entry := decodeLine(t, buf.String())
+	want := map[string]interface{}{"name": "order", "count": 2.0}
+	if !reflect.DeepEqual(entry["req"], want) {
+		t.Errorf("got req %v, want %v", entry["req"], want)
+	}
+}
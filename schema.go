@@ -0,0 +1,127 @@
+package easylog
+
+import (
+	"github.com/logerror/easylog/pkg/option"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const schemaViolationKey = "schema_violation"
+
+// SchemaLogger returns the current global logger, as set by InitGlobalLogger
+// or ReplaceLogger, enforcing that every entry carries
+// the fields named required, added with With or when logging, e.g. actor and
+// action for audit logs. Entries missing some are still written, with the
+// missing names as the schema_violation field, and passed to the handler set
+// with option.WithSchemaViolationHandler, e.g. to fail a test.
+func SchemaLogger(required ...string) Logger {
+	opt := zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return newSchemaCore(core, required)
+	})
+	l, ok := globalLogger.(*logger)
+	if !ok {
+		return newLogger(globalLogger.CoreLogger().WithOptions(opt))
+	}
+	lg := l.logger.WithOptions(opt)
+	otelLogger := l.otelLogger.WithOptions(opt)
+	return &logger{
+		level:             l.level,
+		atomicLevel:       l.atomicLevel,
+		config:            l.config,
+		logger:            lg,
+		sugaredLogger:     lg.Sugar(),
+		otelLogger:        otelLogger,
+		otelSugaredLogger: otelLogger.Sugar(),
+	}
+}
+
+// schemaCore adds the schema_violation field to the entries missing some of
+// its required fields. It checks entries with the wrapped core and writes
+// them through the resulting CheckedEntry, so that they only get the field
+// once they are known to be logged. That CheckedEntry reports write errors
+// to the ErrorOutput of the logger, like the entry it is part of.
+type schemaCore struct {
+	zapcore.Core
+	required []string
+	// present are the keys of the fields added with With.
+	present map[string]struct{}
+}
+
+func newSchemaCore(core zapcore.Core, required []string) zapcore.Core {
+	return &schemaCore{
+		Core:     core,
+		required: required,
+		present:  make(map[string]struct{}),
+	}
+}
+
+func (c *schemaCore) With(fields []zapcore.Field) zapcore.Core {
+	present := make(map[string]struct{}, len(c.present)+len(fields))
+	for key := range c.present {
+		present[key] = struct{}{}
+	}
+	for _, f := range fields {
+		present[f.Key] = struct{}{}
+	}
+	return &schemaCore{
+		Core:     c.Core.With(fields),
+		required: c.required,
+		present:  present,
+	}
+}
+
+func (c *schemaCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	checked := c.Core.Check(ent, nil)
+	if checked == nil {
+		return ce
+	}
+	w := &schemaWriter{schemaCore: c, checked: checked}
+	ce = ce.AddCore(ent, w)
+	w.outer = ce
+	return ce
+}
+
+// missing returns the required fields neither added with With nor in
+// fields.
+func (c *schemaCore) missing(fields []zapcore.Field) []string {
+	var missing []string
+	for _, key := range c.required {
+		if _, ok := c.present[key]; ok {
+			continue
+		}
+		found := false
+		for _, f := range fields {
+			if f.Key == key {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// schemaWriter writes one entry checked by a schemaCore.
+type schemaWriter struct {
+	*schemaCore
+	checked *zapcore.CheckedEntry
+	// outer is the entry checked by the logger, whose ErrorOutput is only
+	// set after Check
+	outer *zapcore.CheckedEntry
+}
+
+func (w *schemaWriter) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if missing := w.missing(fields); len(missing) > 0 {
+		fields = append(fields[:len(fields):len(fields)], zap.Strings(schemaViolationKey, missing))
+		if handler := option.SchemaViolationHandler; handler != nil {
+			handler(ent.Message, missing)
+		}
+	}
+	// the caller, the stack and the error output are only set after Check
+	w.checked.Entry = ent
+	w.checked.ErrorOutput = w.outer.ErrorOutput
+	w.checked.Write(fields...)
+	return nil
+}
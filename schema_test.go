@@ -0,0 +1,65 @@
+package easylog
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/logerror/easylog/pkg/option"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestSchemaLogger(t *testing.T) {
+	var violations []string
+	buf := initGlobalBufferLogger(t, option.WithSchemaViolationHandler(func(msg string, missing []string) {
+		violations = append(violations, msg+": "+strings.Join(missing, ","))
+	}))
+	audit := SchemaLogger("actor", "action")
+
+	audit.Info("complete", zap.String("actor", "ada"), zap.String("action", "delete"))
+	audit.With(zap.String("actor", "ada")).Info("partial")
+	audit.Info("empty")
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 3 {
+		t.Fatalf("got %d lines, want 3", len(entries))
+	}
+	wants := []interface{}{nil, []interface{}{"action"}, []interface{}{"actor", "action"}}
+	for i, want := range wants {
+		if got := entries[i][schemaViolationKey]; !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: got schema_violation %v, want %v", entries[i]["msg"], got, want)
+		}
+		if caller, _ := entries[i]["caller"].(string); !strings.Contains(caller, "schema_test.go:") {
+			t.Errorf("%v: got caller %v, want schema_test.go", entries[i]["msg"], entries[i]["caller"])
+		}
+	}
+	if want := []string{"partial: action", "empty: actor,action"}; !reflect.DeepEqual(violations, want) {
+		t.Errorf("got violations %q passed to the handler, want %q", violations, want)
+	}
+}
+
+func TestSchemaLoggerReplacedLogger(t *testing.T) {
+	initGlobalBufferLogger(t)
+	buf := &syncBuffer{}
+	errOut := &syncBuffer{}
+	ReplaceLogger(NewLogger(zap.New(
+		zapcore.NewCore(zapcore.NewJSONEncoder(newEncoderConfig()), zapcore.AddSync(buf), zapcore.DebugLevel),
+		zap.ErrorOutput(zapcore.AddSync(errOut)),
+	)))
+
+	SchemaLogger("actor").Info("replaced")
+	if entry := decodeLine(t, buf.String()); entry["msg"] != "replaced" {
+		t.Errorf("got %v, want the entry written to the replaced logger", entry)
+	}
+
+	ReplaceLogger(NewLogger(zap.New(
+		zapcore.NewCore(zapcore.NewJSONEncoder(newEncoderConfig()), failingSyncer{err: errors.New("disk full")}, zapcore.DebugLevel),
+		zap.ErrorOutput(zapcore.AddSync(errOut)),
+	)))
+	SchemaLogger("actor").Info("lost")
+	if out := errOut.String(); !strings.Contains(out, "disk full") {
+		t.Errorf("got error output %q, want the write error", out)
+	}
+}
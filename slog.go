@@ -0,0 +1,163 @@
+//go:build go1.21
+
+package easylog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// slogHandler is a slog.Handler writing records to the core of a Logger.
+type slogHandler struct {
+	core zapcore.Core
+	// groups are the groups opened by WithGroup without attributes yet;
+	// they are left out unless attributes are added to them.
+	groups []string
+}
+
+// NewSlogHandler returns a slog.Handler writing the records of a slog.Logger
+// through l, for libraries logging with log/slog, e.g.
+// slog.New(easylog.NewSlogHandler(easylog.DefaultLogger())). Levels map to
+// the zap level they are at least, groups become nested objects and the
+// source of a record becomes the caller of its entry.
+func NewSlogHandler(l Logger) slog.Handler {
+	return &slogHandler{core: l.CoreLogger().Core()}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.core.Enabled(zapLevel(level))
+}
+
+func (h *slogHandler) Handle(_ context.Context, record slog.Record) error {
+	ent := zapcore.Entry{
+		Level:   zapLevel(record.Level),
+		Time:    record.Time,
+		Message: record.Message,
+	}
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		ent.Caller = zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+		ent.Caller.Function = frame.Function
+	}
+	ce := h.core.Check(ent, nil)
+	if ce == nil {
+		return nil
+	}
+
+	fields := make([]zapcore.Field, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		if field, ok := slogField(attr); ok {
+			fields = append(fields, field)
+		}
+		return true
+	})
+	if len(fields) > 0 {
+		fields = append(groupFields(h.groups), fields...)
+	}
+	ce.Write(fields...)
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := groupFields(h.groups)
+	for _, attr := range attrs {
+		if field, ok := slogField(attr); ok {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == len(h.groups) {
+		return h
+	}
+	return &slogHandler{core: h.core.With(fields)}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &slogHandler{
+		core:   h.core,
+		groups: append(h.groups[:len(h.groups):len(h.groups)], name),
+	}
+}
+
+// zapLevel returns the zap level of a slog level, the highest one it
+// reaches, e.g. info for slog.LevelInfo+2.
+func zapLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+// groupFields returns the fields opening the nested objects of groups.
+func groupFields(groups []string) []zapcore.Field {
+	fields := make([]zapcore.Field, 0, len(groups))
+	for _, group := range groups {
+		fields = append(fields, zap.Namespace(group))
+	}
+	return fields
+}
+
+// slogField returns the field of attr, reporting false for the attributes
+// slog handlers ignore: empty ones and groups without attributes.
+func slogField(attr slog.Attr) (zapcore.Field, bool) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return zapcore.Field{}, false
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindBool:
+		return zap.Bool(attr.Key, attr.Value.Bool()), true
+	case slog.KindDuration:
+		return zap.Duration(attr.Key, attr.Value.Duration()), true
+	case slog.KindFloat64:
+		return zap.Float64(attr.Key, attr.Value.Float64()), true
+	case slog.KindInt64:
+		return zap.Int64(attr.Key, attr.Value.Int64()), true
+	case slog.KindString:
+		return zap.String(attr.Key, attr.Value.String()), true
+	case slog.KindTime:
+		return zap.Time(attr.Key, attr.Value.Time()), true
+	case slog.KindUint64:
+		return zap.Uint64(attr.Key, attr.Value.Uint64()), true
+	case slog.KindGroup:
+		attrs := attr.Value.Group()
+		if len(attrs) == 0 {
+			return zapcore.Field{}, false
+		}
+		if attr.Key == "" {
+			// the attributes of a group without key are inlined
+			return zap.Inline(slogGroup(attrs)), true
+		}
+		return zap.Object(attr.Key, slogGroup(attrs)), true
+	default:
+		if err, ok := attr.Value.Any().(error); ok {
+			return zap.NamedError(attr.Key, err), true
+		}
+		return zap.Any(attr.Key, attr.Value.Any()), true
+	}
+}
+
+// slogGroup encodes the attributes of a group as an object.
+type slogGroup []slog.Attr
+
+func (g slogGroup) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, attr := range g {
+		if field, ok := slogField(attr); ok {
+			field.AddTo(enc)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+//go:build go1.21
+
+package easylog
+
+import (
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/logerror/easylog/pkg/option"
+)
+
+func TestSlogHandler(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithLogLevel("debug"))
+	sl := slog.New(NewSlogHandler(l))
+
+	sl.With("service", "billing").WithGroup("req").Info("handled",
+		"method", "GET",
+		slog.Group("user", "id", 42),
+	)
+	sl.Debug("debug")
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 2 {
+		t.Fatalf("got %d lines, want 2", len(entries))
+	}
+	entry := entries[0]
+	if entry["level"] != "info" || entry["msg"] != "handled" || entry["service"] != "billing" {
+		t.Errorf("got %v, want info handled with service=billing", entry)
+	}
+	want := map[string]interface{}{
+		"method": "GET",
+		"user":   map[string]interface{}{"id": 42.0},
+	}
+	if !reflect.DeepEqual(entry["req"], want) {
+		t.Errorf("got req %v, want %v", entry["req"], want)
+	}
+	if caller, _ := entry["caller"].(string); !strings.Contains(caller, "slog_test.go:") {
+		t.Errorf("got caller %v, want slog_test.go", entry["caller"])
+	}
+	if entries[1]["level"] != "debug" {
+		t.Errorf("got level %v, want debug", entries[1]["level"])
+	}
+}
@@ -0,0 +1,54 @@
+package easylog
+
+import (
+	"bytes"
+	"io"
+	"log"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// StdLogAt returns a standard library logger writing every line through l
+// at the named level, info if unknown, e.g. for http.Server.ErrorLog. The
+// entries report the caller of the standard library logger.
+func StdLogAt(l Logger, level string) *log.Logger {
+	// the standard library logger is called directly rather than through the
+	// methods of logger, which the core logger skips
+	lg := l.CoreLogger().WithOptions(zap.AddCallerSkip(-1))
+	stdLog, err := zap.NewStdLogAt(lg, ParseLevel(level))
+	if err != nil {
+		// only returned for levels ParseLevel never returns
+		panic(err)
+	}
+	return stdLog
+}
+
+// Writer returns a writer logging every line written to it through the
+// global logger at the named level, info if unknown, for libraries writing
+// their logs to an io.Writer. Each Write is expected to hold whole lines,
+// as the writes of a standard library logger do; empty lines are dropped.
+func Writer(level string) io.Writer {
+	return &lineWriter{
+		logger: globalLogger.CoreLogger().WithOptions(zap.WithCaller(false)),
+		level:  ParseLevel(level),
+	}
+}
+
+// lineWriter logs each line written to it as an entry.
+type lineWriter struct {
+	logger *zap.Logger
+	level  zapcore.Level
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	for _, line := range bytes.Split(p, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		if ce := w.logger.Check(w.level, string(line)); ce != nil {
+			ce.Write()
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,40 @@
+package easylog
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/logerror/easylog/pkg/option"
+)
+
+func TestStdLogAt(t *testing.T) {
+	l, buf := newBufferLogger(t)
+
+	StdLogAt(l, "warn").Print("legacy")
+
+	entry := decodeLine(t, buf.String())
+	if entry["msg"] != "legacy" || entry["level"] != "warn" {
+		t.Errorf("got %v, want legacy at warn", entry)
+	}
+	if caller, _ := entry["caller"].(string); !strings.Contains(caller, "stdlog_test.go:") {
+		t.Errorf("got caller %v, want stdlog_test.go", entry["caller"])
+	}
+}
+
+func TestWriter(t *testing.T) {
+	buf := initGlobalBufferLogger(t, option.WithLogLevel("debug"))
+
+	fmt.Fprint(Writer("error"), "first\nsecond\n\n")
+	fmt.Fprintln(Writer("debug"), "third")
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 3 {
+		t.Fatalf("got %d lines, want 3", len(entries))
+	}
+	for i, want := range [][2]string{{"first", "error"}, {"second", "error"}, {"third", "debug"}} {
+		if entries[i]["msg"] != want[0] || entries[i]["level"] != want[1] {
+			t.Errorf("got %v, want %s at %s", entries[i], want[0], want[1])
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package easylog
+
+import (
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/logerror/easylog/pkg/option"
+)
+
+func TestWithOSThreadField(t *testing.T) {
+	l, buf := newBufferLogger(t, option.WithOSThreadField())
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	l.Info("threaded")
+
+	tid, ok := decodeLine(t, buf.String())["tid"].(float64)
+	if !ok || tid <= 0 {
+		t.Fatalf("got tid %v, want a positive number", tid)
+	}
+	if int(tid) != syscall.Gettid() {
+		t.Errorf("got tid %v, want %d", tid, syscall.Gettid())
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package easylog
+
+// osThreadID reports false: thread ids are only read on Linux.
+func osThreadID() (int, bool) {
+	return 0, false
+}
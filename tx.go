@@ -0,0 +1,20 @@
+package easylog
+
+import "context"
+
+const inTxKey = "in_tx"
+
+type inTxContextKey struct{}
+
+// WithTx returns ctx noting whether the code it is passed to runs inside an
+// open database transaction. G and GS log it as the in_tx field, false for
+// contexts never passed to WithTx.
+func WithTx(ctx context.Context, inTx bool) context.Context {
+	return context.WithValue(ctx, inTxContextKey{}, inTx)
+}
+
+// InTx reports whether ctx was marked by WithTx as inside a transaction.
+func InTx(ctx context.Context) bool {
+	inTx, _ := ctx.Value(inTxContextKey{}).(bool)
+	return inTx
+}
@@ -0,0 +1,25 @@
+package easylog
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithTx(t *testing.T) {
+	buf := initGlobalBufferLogger(t)
+	ctx := WithTx(context.Background(), true)
+
+	G(ctx).Info("logger")
+	GS(ctx).Info("sugared")
+	G(context.Background()).Info("unmarked")
+
+	entries := decodeLines(t, buf.String())
+	if len(entries) != 3 {
+		t.Fatalf("got %d lines, want 3", len(entries))
+	}
+	for i, want := range []bool{true, true, false} {
+		if got := entries[i][inTxKey]; got != want {
+			t.Errorf("%v: got in_tx %v, want %v", entries[i]["msg"], got, want)
+		}
+	}
+}
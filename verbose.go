@@ -0,0 +1,40 @@
+package easylog
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/logerror/easylog/pkg/option"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// verboseTraces maps the trace ids registered by TraceVerbose, in lowercase
+// hex, to their level.
+var verboseTraces sync.Map
+
+// TraceVerbose makes G and GS log the entries at or above level within the
+// trace with the given hex id, whatever the level of the global logger, e.g.
+// debug entries of a request under investigation.
+func TraceVerbose(traceID string, level option.Level) {
+	verboseTraces.Store(strings.ToLower(traceID), level)
+}
+
+// ResetTraceVerbose reverts TraceVerbose for the trace with the given hex id.
+func ResetTraceVerbose(traceID string) {
+	verboseTraces.Delete(strings.ToLower(traceID))
+}
+
+// verboseLevel returns the level registered by TraceVerbose for the trace of
+// the span in ctx.
+func verboseLevel(ctx context.Context) (option.Level, bool) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return 0, false
+	}
+	level, ok := verboseTraces.Load(spanContext.TraceID().String())
+	if !ok {
+		return 0, false
+	}
+	return level.(option.Level), true
+}
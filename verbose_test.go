@@ -0,0 +1,33 @@
+package easylog
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/logerror/easylog/pkg/option"
+)
+
+func TestTraceVerbose(t *testing.T) {
+	buf := initGlobalBufferLogger(t, option.WithLogLevel("info"))
+	tracer, _ := newTracer(t)
+	verboseCtx, verbose := tracer.Start(context.Background(), "investigated")
+	defer verbose.End()
+	ctx, span := tracer.Start(context.Background(), "other")
+	defer span.End()
+
+	TraceVerbose(verbose.SpanContext().TraceID().String(), DebugLevel)
+	G(verboseCtx).Debug("verbose")
+	GS(verboseCtx).Debug("verbose too")
+	G(ctx).Debug("dropped")
+	ResetTraceVerbose(verbose.SpanContext().TraceID().String())
+	G(verboseCtx).Debug("reset")
+
+	var msgs []string
+	for _, entry := range decodeLines(t, buf.String()) {
+		msgs = append(msgs, entry["msg"].(string))
+	}
+	if strings.Join(msgs, ",") != "verbose,verbose too" {
+		t.Errorf("got messages %v, want verbose and verbose too", msgs)
+	}
+}
@@ -0,0 +1,179 @@
+package easylog
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// lastSyncError holds the last error writing or syncing a log file.
+var lastSyncError atomic.Value
+
+// errorHandlingSyncer records the write and sync errors of the wrapped
+// syncer and reports them to onError, if set, before returning them.
+type errorHandlingSyncer struct {
+	zapcore.WriteSyncer
+	onError func(error)
+}
+
+func newErrorHandlingSyncer(ws zapcore.WriteSyncer, onError func(error)) zapcore.WriteSyncer {
+	return &errorHandlingSyncer{
+		WriteSyncer: ws,
+		onError:     onError,
+	}
+}
+
+func (s *errorHandlingSyncer) Write(p []byte) (int, error) {
+	n, err := s.WriteSyncer.Write(p)
+	if err != nil {
+		s.handle(err)
+	}
+	return n, err
+}
+
+func (s *errorHandlingSyncer) Sync() error {
+	err := s.WriteSyncer.Sync()
+	if err != nil {
+		s.handle(err)
+	}
+	return err
+}
+
+func (s *errorHandlingSyncer) handle(err error) {
+	lastSyncError.Store(err.Error())
+	if s.onError != nil {
+		s.onError(err)
+	}
+}
+
+// swappableSyncer forwards to a WriteSyncer that can be replaced at runtime.
+type swappableSyncer struct {
+	v atomic.Value
+}
+
+// syncerHolder keeps the concrete type stored in swappableSyncer.v constant.
+type syncerHolder struct {
+	zapcore.WriteSyncer
+}
+
+func newSwappableSyncer(ws zapcore.WriteSyncer) *swappableSyncer {
+	s := &swappableSyncer{}
+	s.swap(ws)
+	return s
+}
+
+func (s *swappableSyncer) swap(ws zapcore.WriteSyncer) {
+	s.v.Store(syncerHolder{ws})
+}
+
+func (s *swappableSyncer) load() zapcore.WriteSyncer {
+	return s.v.Load().(syncerHolder).WriteSyncer
+}
+
+func (s *swappableSyncer) Write(p []byte) (int, error) {
+	return s.load().Write(p)
+}
+
+func (s *swappableSyncer) Sync() error {
+	return s.load().Sync()
+}
+
+// SetOutput atomically replaces the console output of the global logger
+// with w. It has no effect when console output is disabled or the global
+// logger was not built by InitGlobalLogger, e.g. during Capture.
+func SetOutput(w io.Writer) {
+	if s := globalRawLogger.consoleSyncer; s != nil {
+		s.swap(zapcore.AddSync(w))
+	}
+}
+
+// channelSinkDropped counts the entries dropped by full channel sinks.
+var channelSinkDropped uint64
+
+// ChannelSinkDropped returns the number of entries dropped so far because
+// the channel sink was full.
+func ChannelSinkDropped() uint64 {
+	return atomic.LoadUint64(&channelSinkDropped)
+}
+
+// newChannelCore returns a core sending entries to ch without blocking.
+func newChannelCore(ch chan<- LoggedEntry) zapcore.Core {
+	return newEntryCore(func(entry LoggedEntry) {
+		select {
+		case ch <- entry:
+		default:
+			atomic.AddUint64(&channelSinkDropped, 1)
+		}
+	})
+}
+
+// entryCore passes every entry with its fields to write.
+type entryCore struct {
+	write   func(LoggedEntry)
+	context []zapcore.Field
+}
+
+func newEntryCore(write func(LoggedEntry)) zapcore.Core {
+	return &entryCore{write: write}
+}
+
+// Enabled enables every level, the level is enforced by the wrapping levelCore.
+func (c *entryCore) Enabled(zapcore.Level) bool {
+	return true
+}
+
+func (c *entryCore) With(fields []zapcore.Field) zapcore.Core {
+	return &entryCore{
+		write:   c.write,
+		context: append(c.context[:len(c.context):len(c.context)], fields...),
+	}
+}
+
+func (c *entryCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(ent, c)
+}
+
+func (c *entryCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	c.write(LoggedEntry{
+		Entry:   ent,
+		Context: append(c.context[:len(c.context):len(c.context)], fields...),
+	})
+	return nil
+}
+
+func (c *entryCore) Sync() error {
+	return nil
+}
+
+// lazyFileSyncer appends to the file at path, which is only created by the
+// first write.
+type lazyFileSyncer struct {
+	path string
+	once sync.Once
+	file *os.File
+	err  error
+}
+
+func newLazyFileSyncer(path string) zapcore.WriteSyncer {
+	return zapcore.Lock(&lazyFileSyncer{path: path})
+}
+
+func (s *lazyFileSyncer) Write(p []byte) (int, error) {
+	s.once.Do(func() {
+		s.file, s.err = os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	})
+	if s.err != nil {
+		return 0, s.err
+	}
+	return s.file.Write(p)
+}
+
+func (s *lazyFileSyncer) Sync() error {
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Sync()
+}
@@ -0,0 +1,80 @@
+package easylog
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/logerror/easylog/pkg/option"
+	"go.uber.org/zap"
+)
+
+// failingSyncer fails every write and sync with err.
+type failingSyncer struct {
+	err error
+}
+
+func (s failingSyncer) Write([]byte) (int, error) { return 0, s.err }
+func (s failingSyncer) Sync() error               { return s.err }
+
+func TestErrorHandlingSyncer(t *testing.T) {
+	errFull := errors.New("no space left on device")
+	var got []error
+	ws := newErrorHandlingSyncer(failingSyncer{err: errFull}, func(err error) {
+		got = append(got, err)
+	})
+
+	if _, err := ws.Write([]byte("line\n")); err != errFull {
+		t.Errorf("got write error %v, want %v", err, errFull)
+	}
+	if err := ws.Sync(); err != errFull {
+		t.Errorf("got sync error %v, want %v", err, errFull)
+	}
+	if len(got) != 2 || got[0] != errFull || got[1] != errFull {
+		t.Errorf("got handled errors %v, want the write and sync errors", got)
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	a := initGlobalBufferLogger(t)
+
+	Info("to a")
+	b := &syncBuffer{}
+	SetOutput(b)
+	Info("to b")
+
+	if entry := decodeLine(t, a.String()); entry["msg"] != "to a" {
+		t.Errorf("got msg %v in a, want to a", entry["msg"])
+	}
+	if entry := decodeLine(t, b.String()); entry["msg"] != "to b" {
+		t.Errorf("got msg %v in b, want to b", entry["msg"])
+	}
+}
+
+func TestSetOutputDuringCapture(t *testing.T) {
+	initGlobalBufferLogger(t)
+
+	Capture(func() {
+		SetOutput(&syncBuffer{})
+	})
+}
+
+func TestWithChannelSink(t *testing.T) {
+	ch := make(chan LoggedEntry, 2)
+	l, _ := newBufferLogger(t, option.WithChannelSink(ch))
+	dropped := ChannelSinkDropped()
+
+	l.With(zap.String("k", "v")).Warn("first")
+	l.Error("second")
+	l.Info("dropped")
+
+	first, second := <-ch, <-ch
+	if first.Level != WarnLevel || first.Message != "first" || first.ContextMap()["k"] != "v" {
+		t.Errorf("got %v %q %v, want warn first with k=v", first.Level, first.Message, first.ContextMap())
+	}
+	if second.Level != ErrorLevel || second.Message != "second" {
+		t.Errorf("got %v %q, want error second", second.Level, second.Message)
+	}
+	if d := ChannelSinkDropped() - dropped; d != 1 {
+		t.Errorf("got %d entries dropped, want 1", d)
+	}
+}